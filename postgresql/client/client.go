@@ -2,23 +2,46 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"errors"
 	"fmt"
 	"hash/fnv"
+	"net"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+)
+
+// server_version_num of the major versions features are gated on
+const (
+	Version14 = 140000
+	Version15 = 150000
+	Version16 = 160000
+	Version17 = 170000
 )
 
 type Client struct {
-	host               string
+	hosts              []hostPort
 	port               int
+	params             map[string]string
+	targetSessionAttrs string
 	defaultDatabase    string
 	username           string
-	password           string
+	passwordSource     passwordSource
 	maxOpenConnections int
 	maxIdleConnections int
+	sslMode            string
+	tlsConfig          *tls.Config
+	sshTunnel          *sshTunnel
+	retryConfig        RetryConfig
+	assumeRole         string
 	conns              map[string]*sql.DB
+	versions           map[string]int
 	mu                 sync.Mutex
 }
 type DatabaseNotExistError struct {
@@ -29,16 +52,127 @@ func (e *DatabaseNotExistError) Error() string {
 	return fmt.Sprintf("database %s does not exist", e.Database)
 }
 
-func NewClient(host string, port int, defaultDatabase string, username string, password string, maxOpenConnections int, maxIdleConnections int) (*Client, error) {
+// ConnectionError is returned by GetConn for every connection failure other than a missing database, so that
+// callers never mistake an authentication, TLS or network problem for a missing object
+type ConnectionError struct {
+	Database string
+	Reason   string
+	Err      error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("could not connect to database %s (%s): %v", e.Database, e.Reason, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// classifyConnectError turns an error from opening a connection to database into a DatabaseNotExistError or a
+// ConnectionError based on its SQLSTATE or Go error type
+func classifyConnectError(database string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if pqErr.Code == "3D000" {
+			return &DatabaseNotExistError{Database: database}
+		}
+		reason := fmt.Sprintf("server error %s", pqErr.Code.Name())
+		switch pqErr.Code.Class() {
+		case "28":
+			reason = "authentication failed"
+		case "53":
+			reason = "insufficient resources"
+		case "57":
+			reason = "server unavailable"
+		case "08":
+			reason = "connection failure"
+		}
+		return &ConnectionError{Database: database, Reason: fmt.Sprintf("%s, SQLSTATE %s", reason, pqErr.Code), Err: err}
+	}
+	var netErr net.Error
+	var tlsRecordErr tls.RecordHeaderError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	switch {
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthorityErr), errors.As(err, &hostnameErr), errors.As(err, &tlsRecordErr):
+		return &ConnectionError{Database: database, Reason: "TLS error", Err: err}
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return &ConnectionError{Database: database, Reason: "network timeout", Err: err}
+		}
+		return &ConnectionError{Database: database, Reason: "network error", Err: err}
+	}
+	return &ConnectionError{Database: database, Reason: "unexpected error", Err: err}
+}
+
+func NewClient(host string, port int, defaultDatabase string, username string, password string, passwordSourceConfig PasswordSourceConfig, maxOpenConnections int, maxIdleConnections int, sslConfig SSLConfig, awsRDSIAMConfig AWSRDSIAMConfig, targetSessionAttrs string, sshTunnelConfig *SSHTunnelConfig, retryConfig RetryConfig, assumeRole string) (*Client, error) {
+	hosts, params, err := parseTarget(host)
+	if err != nil {
+		return nil, err
+	}
+	// Explicit provider arguments win over the same settings embedded in a connection URI or string
+	defaultDatabase = takeParam(params, "dbname", defaultDatabase)
+	if defaultDatabase == "" {
+		defaultDatabase = "postgres"
+	}
+	username = takeParam(params, "user", username)
+	password = takeParam(params, "password", password)
+	targetSessionAttrs = takeParam(params, "target_session_attrs", targetSessionAttrs)
+	sslConfig.Mode = takeParam(params, "sslmode", sslConfig.Mode)
+	sslConfig.RootCert = takeParam(params, "sslrootcert", sslConfig.RootCert)
+	sslConfig.Cert = takeParam(params, "sslcert", sslConfig.Cert)
+	sslConfig.Key = takeParam(params, "sslkey", sslConfig.Key)
+	sslConfig.KeyPassword = takeParam(params, "sslpassword", sslConfig.KeyPassword)
 	c := &Client{
-		host:               host,
+		hosts:              hosts,
 		port:               port,
+		params:             params,
+		targetSessionAttrs: targetSessionAttrs,
 		defaultDatabase:    defaultDatabase,
 		username:           username,
-		password:           password,
 		maxOpenConnections: maxOpenConnections,
 		maxIdleConnections: maxIdleConnections,
+		sslMode:            sslConfig.Mode,
+		retryConfig:        retryConfig,
+		assumeRole:         assumeRole,
 		conns:              make(map[string]*sql.DB),
+		versions:           make(map[string]int),
+	}
+	// Certificates without a mode imply TLS is wanted
+	if (c.sslMode == "") && ((sslConfig.RootCert != "") || (sslConfig.Cert != "")) {
+		c.sslMode = SSLModeRequire
+	}
+	if (c.sslMode != "") && (c.sslMode != SSLModeDisable) {
+		sslConfig.Mode = c.sslMode
+		tlsConfig, err := newTLSConfig(sslConfig)
+		if err != nil {
+			return nil, err
+		}
+		c.tlsConfig = tlsConfig
+	}
+	if sshTunnelConfig != nil {
+		tunnel, err := newSSHTunnel(*sshTunnelConfig)
+		if err != nil {
+			return nil, err
+		}
+		c.sshTunnel = tunnel
+	}
+	if awsRDSIAMConfig.Enabled {
+		if (len(passwordSourceConfig.Command) > 0) || (passwordSourceConfig.File != "") {
+			return nil, errors.New("aws_rds_iam_auth cannot be combined with password_command or password_file")
+		}
+		tokenSource, err := newRDSIAMTokenSource(context.Background(), awsRDSIAMConfig, username)
+		if err != nil {
+			return nil, err
+		}
+		c.passwordSource = tokenSource
+	} else {
+		source, err := newPasswordSource(password, passwordSourceConfig)
+		if err != nil {
+			return nil, err
+		}
+		c.passwordSource = source
 	}
 	return c, nil
 }
@@ -53,50 +187,184 @@ func (c *Client) GetConn(database string) (*sql.DB, error) {
 	if exists {
 		return conn, nil
 	}
-	conn, err := sql.Open("postgres", fmt.Sprintf("postgres://%s:%s@%s:%d/%s", c.username, c.password, c.host, c.port, database))
-	if err != nil {
-		return nil, err
-	}
+	conn = sql.OpenDB(&connector{client: c, database: database})
 	conn.SetMaxOpenConns(c.maxOpenConnections)
 	conn.SetMaxIdleConns(c.maxIdleConnections)
-	// Verify database exists
-	err = conn.Ping()
+	// Verify database exists and is reachable
+	err := conn.Ping()
+	if err != nil {
+		conn.Close()
+		return nil, classifyConnectError(database, err)
+	}
+	var versionNum string
+	err = conn.QueryRow("show server_version_num").Scan(&versionNum)
 	if err != nil {
-		return nil, &DatabaseNotExistError{Database: database}
+		conn.Close()
+		return nil, fmt.Errorf("Error detecting server version: %w", err)
+	}
+	version, err := strconv.Atoi(versionNum)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("Error parsing server_version_num %s: %w", versionNum, err)
 	}
 	c.conns[database] = conn
+	c.versions[database] = version
 	return conn, nil
 }
 
-func (c *Client) QueryRow(ctx context.Context, database string, queryTemplate string, args ...any) (string, *sql.Row, error) {
-	conn, err := c.GetConn(database)
+// CloseConn closes and forgets the connections to database, which is required before it can be renamed, moved or
+// dropped
+func (c *Client) CloseConn(database string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conn, exists := c.conns[database]
+	if !exists {
+		return
+	}
+	conn.Close()
+	delete(c.conns, database)
+	delete(c.versions, database)
+}
+
+// MaintenanceDatabase returns the database to connect to for statements about database, which cannot be run while
+// connected to it, like dropping it.  That is the default database, unless it is database itself.
+func (c *Client) MaintenanceDatabase(database string) string {
+	if database != c.defaultDatabase {
+		return ""
+	}
+	if database != "postgres" {
+		return "postgres"
+	}
+	return "template1"
+}
+
+// ServerVersion returns the server_version_num (like 160002) of the server behind database
+func (c *Client) ServerVersion(database string) (int, error) {
+	if database == "" {
+		database = c.defaultDatabase
+	}
+	_, err := c.GetConn(database)
 	if err != nil {
-		return "", nil, err
+		return 0, err
 	}
-	var stats = conn.Stats()
-	tflog.Error(ctx, "PostgreSQL Stats:", map[string]any{"InUse": stats.InUse, "Idle": stats.Idle, "Open": stats.OpenConnections})
-	query := fmt.Sprintf(queryTemplate, args...)
-	tflog.Info(ctx, "PostgreSQL SQL:", map[string]any{"SQL": query})
-	return query, conn.QueryRowContext(ctx, query), nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.versions[database], nil
 }
 
-func (c *Client) Query(ctx context.Context, database string, queryTemplate string, args ...any) (string, *sql.Rows, error) {
-	conn, err := c.GetConn(database)
+// RequireServerVersion returns a descriptive error if the server behind database is older than minVersion
+func (c *Client) RequireServerVersion(database string, minVersion int, feature string) error {
+	version, err := c.ServerVersion(database)
+	if err != nil {
+		return err
+	}
+	if version < minVersion {
+		return fmt.Errorf("%s requires PostgreSQL %d or later, but the server is running PostgreSQL %d", feature, minVersion/10000, version/10000)
+	}
+	return nil
+}
+
+// takeParam removes key from params and returns value, or the removed parameter if value is empty
+func takeParam(params map[string]string, key string, value string) string {
+	param, ok := params[key]
+	delete(params, key)
+	if (value == "") && ok {
+		return param
+	}
+	return value
+}
+
+// dsn returns the connection string for database on host:port
+func (c *Client) dsn(database string, host string, port int, password string) string {
+	params := map[string]string{}
+	for key, value := range c.params {
+		params[key] = value
+	}
+	params["host"] = host
+	params["port"] = strconv.Itoa(port)
+	params["dbname"] = database
+	if c.username != "" {
+		params["user"] = c.username
+	}
+	if password != "" {
+		params["password"] = password
+	}
+	if c.tlsConfig != nil {
+		// TLS is negotiated by pqDialer, so the driver must not attempt it again
+		params["sslmode"] = SSLModeDisable
+	} else if c.sslMode != "" {
+		params["sslmode"] = c.sslMode
+	}
+	return keywordValues(params)
+}
+
+// address formats hp for messages
+func (c *Client) address(hp hostPort) string {
+	port := c.port
+	if hp.port != 0 {
+		port = hp.port
+	}
+	if strings.HasPrefix(hp.host, "/") {
+		return fmt.Sprintf("%s/.s.PGSQL.%d", hp.host, port)
+	}
+	return net.JoinHostPort(hp.host, strconv.Itoa(port))
+}
+
+// QueryRow runs query against database.  Values must be passed as bind parameters ($1, $2, ...) in args,
+// identifiers and literals that cannot be bound must already be quoted with QuoteIdentifier/QuoteLiteral.
+func (c *Client) QueryRow(ctx context.Context, database string, query string, args ...any) (string, *sql.Row, error) {
+	var row *sql.Row
+	err := c.retry(ctx, isRetryableError, func() error {
+		conn, err := c.GetConn(database)
+		if err != nil {
+			return err
+		}
+		var stats = conn.Stats()
+		tflog.Error(ctx, "PostgreSQL Stats:", map[string]any{"InUse": stats.InUse, "Idle": stats.Idle, "Open": stats.OpenConnections})
+		tflog.Info(ctx, "PostgreSQL SQL:", map[string]any{"SQL": RedactSQL(query)})
+		row = conn.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
 	if err != nil {
+		// The row of an earlier attempt is stale once a later attempt failed
 		return "", nil, err
 	}
-	var stats = conn.Stats()
-	tflog.Error(ctx, "PostgreSQL Stats:", map[string]any{"InUse": stats.InUse, "Idle": stats.Idle, "Open": stats.OpenConnections})
-	query := fmt.Sprintf(queryTemplate, args...)
-	tflog.Info(ctx, "PostgreSQL SQL:", map[string]any{"SQL": query})
-	rows, err := conn.QueryContext(ctx, query)
-	return query, rows, err
+	return RedactSQL(query), row, nil
+}
+
+// Query runs query against database.  See QueryRow for how args are handled.
+func (c *Client) Query(ctx context.Context, database string, query string, args ...any) (string, *sql.Rows, error) {
+	var rows *sql.Rows
+	err := c.retry(ctx, isRetryableError, func() error {
+		conn, err := c.GetConn(database)
+		if err != nil {
+			return err
+		}
+		var stats = conn.Stats()
+		tflog.Error(ctx, "PostgreSQL Stats:", map[string]any{"InUse": stats.InUse, "Idle": stats.Idle, "Open": stats.OpenConnections})
+		tflog.Info(ctx, "PostgreSQL SQL:", map[string]any{"SQL": RedactSQL(query)})
+		rows, err = conn.QueryContext(ctx, query, args...)
+		return err
+	})
+	return RedactSQL(query), rows, err
 }
 
-func (c *Client) Exec(ctx context.Context, database string, resourceLockName string, queryTemplate string, args ...any) (string, sql.Result, error) {
+// Exec runs query against database, optionally serialized by an advisory lock named resourceLockName.
+// See QueryRow for how args are handled.
+func (c *Client) Exec(ctx context.Context, database string, resourceLockName string, query string, args ...any) (string, sql.Result, error) {
+	var result sql.Result
+	err := c.retry(ctx, isRetryableExecError, func() error {
+		var err error
+		result, err = c.execOnce(ctx, database, resourceLockName, query, args...)
+		return err
+	})
+	return RedactSQL(query), result, err
+}
+
+func (c *Client) execOnce(ctx context.Context, database string, resourceLockName string, query string, args ...any) (sql.Result, error) {
 	conn, err := c.GetConn(database)
 	if err != nil {
-		return "", nil, err
+		return nil, &unsentError{err: err}
 	}
 	// Obtain lock
 	var tx *sql.Tx = nil
@@ -105,30 +373,29 @@ func (c *Client) Exec(ctx context.Context, database string, resourceLockName str
 		h.Write([]byte(resourceLockName))
 		var resourceLockId = int64(h.Sum64())
 		tx, err = conn.BeginTx(ctx, nil)
-		defer tx.Rollback()
 		if err != nil {
-			return "", nil, err
+			return nil, &unsentError{err: err}
 		}
-		lockQuery := fmt.Sprintf("SELECT pg_advisory_xact_lock(%d)", resourceLockId)
+		defer tx.Rollback()
+		lockQuery := "SELECT pg_advisory_xact_lock($1)"
 		tflog.Info(ctx, "PostgreSQL SQL:", map[string]any{"SQL": lockQuery})
-		_, err = tx.ExecContext(ctx, lockQuery)
+		_, err = tx.ExecContext(ctx, lockQuery, resourceLockId)
 		if err != nil {
-			return "", nil, err
+			return nil, &unsentError{err: err}
 		}
 	}
 	var stats = conn.Stats()
 	tflog.Error(ctx, "PostgreSQL Stats:", map[string]any{"InUse": stats.InUse, "Idle": stats.Idle, "Open": stats.OpenConnections})
-	query := fmt.Sprintf(queryTemplate, args...)
-	tflog.Info(ctx, "PostgreSQL SQL:", map[string]any{"SQL": query})
+	tflog.Info(ctx, "PostgreSQL SQL:", map[string]any{"SQL": RedactSQL(query)})
 	var result sql.Result
 	if tx != nil {
-		result, err = tx.ExecContext(ctx, query)
+		result, err = tx.ExecContext(ctx, query, args...)
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 		err = tx.Commit()
 	} else {
-		result, err = conn.ExecContext(ctx, query)
+		result, err = conn.ExecContext(ctx, query, args...)
 	}
-	return query, result, err
+	return result, err
 }
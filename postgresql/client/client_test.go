@@ -0,0 +1,75 @@
+package client
+
+import (
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestMaintenanceDatabase(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultDatabase string
+		database        string
+		want            string
+	}{
+		{"other database", "postgres", "mydb", ""},
+		{"default database", "mydb", "mydb", "postgres"},
+		{"default database postgres", "postgres", "postgres", "template1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{defaultDatabase: tt.defaultDatabase}
+			if got := c.MaintenanceDatabase(tt.database); got != tt.want {
+				t.Errorf("MaintenanceDatabase(%q) = %q, want %q", tt.database, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyConnectError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{"authentication", &pq.Error{Code: "28P01"}, "authentication failed, SQLSTATE 28P01"},
+		{"too many connections", &pq.Error{Code: "53300"}, "insufficient resources, SQLSTATE 53300"},
+		{"starting up", &pq.Error{Code: "57P03"}, "server unavailable, SQLSTATE 57P03"},
+		{"connection failure", &pq.Error{Code: "08006"}, "connection failure, SQLSTATE 08006"},
+		{"other server error", &pq.Error{Code: "42501"}, "server error insufficient_privilege, SQLSTATE 42501"},
+		{"unknown authority", x509.UnknownAuthorityError{}, "TLS error"},
+		{"hostname mismatch", x509.HostnameError{Certificate: &x509.Certificate{}, Host: "db1"}, "TLS error"},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, "network error"},
+		{"timeout", &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}, "network timeout"},
+		{"unexpected", errors.New("boom"), "unexpected error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyConnectError("mydb", tt.err)
+			var connErr *ConnectionError
+			if !errors.As(err, &connErr) {
+				t.Fatalf("classifyConnectError() = %T, want *ConnectionError", err)
+			}
+			if connErr.Database != "mydb" {
+				t.Errorf("Database = %q, want %q", connErr.Database, "mydb")
+			}
+			if connErr.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q", connErr.Reason, tt.reason)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("ConnectionError does not wrap the original error")
+			}
+		})
+	}
+	err := classifyConnectError("mydb", &pq.Error{Code: "3D000"})
+	var dneErr *DatabaseNotExistError
+	if !errors.As(err, &dneErr) || (dneErr.Database != "mydb") {
+		t.Errorf("classifyConnectError() of 3D000 = %v, want DatabaseNotExistError for mydb", err)
+	}
+}
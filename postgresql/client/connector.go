@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// connector implements driver.Connector for one database of a Client.  Unlike a fixed DSN, it resolves the
+// password every time the pool opens a new connection so that short-lived credentials can be used, and it tries
+// every configured host until one matches target_session_attrs.
+type connector struct {
+	client   *Client
+	database string
+}
+
+func (cn *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := cn.connectMatching(ctx, cn.client.targetSessionAttrs)
+	if (err != nil) && (cn.client.targetSessionAttrs == TargetSessionAttrsPreferStandby) {
+		conn, err = cn.connectMatching(ctx, TargetSessionAttrsAny)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cn.client.assumeRole != "" {
+		err = assumeRole(ctx, conn, cn.client.assumeRole)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// assumeRole switches the session of conn to role so that everything created through it is owned by role
+func assumeRole(ctx context.Context, conn driver.Conn, role string) error {
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		return errors.New("driver connection does not support statements")
+	}
+	_, err := execer.ExecContext(ctx, fmt.Sprintf("set role %s", pq.QuoteIdentifier(role)), nil)
+	if err != nil {
+		return fmt.Errorf("Error assuming role %s: %w", role, err)
+	}
+	return nil
+}
+
+func (cn *connector) connectMatching(ctx context.Context, targetSessionAttrs string) (driver.Conn, error) {
+	errs := []error{}
+	for _, hp := range cn.client.hosts {
+		conn, err := cn.connectHost(ctx, hp)
+		if err == nil {
+			var matches bool
+			matches, err = matchesTargetSessionAttrs(ctx, conn, targetSessionAttrs)
+			if matches {
+				return conn, nil
+			}
+			conn.Close()
+			if err == nil {
+				err = fmt.Errorf("server does not match target_session_attrs %s", targetSessionAttrs)
+			}
+		}
+		if len(cn.client.hosts) == 1 {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", cn.client.address(hp), err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (cn *connector) connectHost(ctx context.Context, hp hostPort) (driver.Conn, error) {
+	port := cn.client.port
+	if hp.port != 0 {
+		port = hp.port
+	}
+	password, err := cn.client.passwordSource.Password(ctx, hp.host, port)
+	if err != nil {
+		return nil, err
+	}
+	pqConnector, err := pq.NewConnector(cn.client.dsn(cn.database, hp.host, port, password))
+	if err != nil {
+		return nil, err
+	}
+	if (cn.client.tlsConfig != nil) || (cn.client.sshTunnel != nil) {
+		var base contextDialer = &net.Dialer{}
+		if cn.client.sshTunnel != nil {
+			base = cn.client.sshTunnel
+		}
+		pqConnector.Dialer(&pqDialer{base: base, tlsConfig: cn.client.tlsConfig, tlsOptional: (cn.client.sslMode == SSLModeAllow) || (cn.client.sslMode == SSLModePrefer)})
+	}
+	return pqConnector.Connect(ctx)
+}
+
+func (cn *connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// contextDialer opens raw connections to a server, either directly or through the ssh tunnel
+type contextDialer interface {
+	DialContext(ctx context.Context, network string, address string) (net.Conn, error)
+}
+
+// pqDialer implements pq.Dialer.  When TLS is configured it performs the SSLRequest negotiation and TLS handshake
+// itself so that the connection handed to the driver is already encrypted.  The driver is then told to use
+// sslmode=disable.
+type pqDialer struct {
+	base      contextDialer
+	tlsConfig *tls.Config
+	// tlsOptional continues unencrypted when the server refuses TLS
+	tlsOptional bool
+}
+
+func (d *pqDialer) Dial(network string, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+func (d *pqDialer) DialTimeout(network string, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.DialContext(ctx, network, address)
+}
+
+func (d *pqDialer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	conn, err := d.base.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	// Like libpq, never use TLS on unix sockets
+	if (d.tlsConfig == nil) || (network == "unix") {
+		return conn, nil
+	}
+	tlsConn, err := startTLS(ctx, conn, d.tlsConfig, d.tlsOptional, address)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// PasswordSourceConfig configures an external credential source used instead of a static password.  At most one
+// of Command and File may be set.
+type PasswordSourceConfig struct {
+	Command []string
+	File    string
+}
+
+// passwordSource returns the password for a new connection.  It is called every time the pool opens a connection
+// so that rotating credentials are always current.
+type passwordSource interface {
+	Password(ctx context.Context, host string, port int) (string, error)
+}
+
+type staticPasswordSource struct {
+	password string
+}
+
+func (s *staticPasswordSource) Password(ctx context.Context, host string, port int) (string, error) {
+	return s.password, nil
+}
+
+// commandPasswordSource runs a local executable and uses its stdout as the password
+type commandPasswordSource struct {
+	command []string
+}
+
+func (s *commandPasswordSource) Password(ctx context.Context, host string, port int) (string, error) {
+	return RunPasswordCommand(ctx, "password_command", s.command)
+}
+
+// RunPasswordCommand runs command with env added to the environment and returns its stdout without the trailing
+// newline.  argument names the configuration the command came from in errors.
+func RunPasswordCommand(ctx context.Context, argument string, command []string, env ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("Error running %s %s: %w, stderr: %s", argument, command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// filePasswordSource re-reads a file every time a password is needed
+type filePasswordSource struct {
+	file string
+}
+
+func (s *filePasswordSource) Password(ctx context.Context, host string, port int) (string, error) {
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return "", fmt.Errorf("Error reading password_file %s: %w", s.file, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// newPasswordSource picks the password source for the configuration, in order of precedence: external command,
+// file, static password
+func newPasswordSource(password string, config PasswordSourceConfig) (passwordSource, error) {
+	if (len(config.Command) > 0) && (config.File != "") {
+		return nil, errors.New("only one of password_command and password_file can be specified")
+	}
+	if len(config.Command) > 0 {
+		if config.Command[0] == "" {
+			return nil, errors.New("password_command must start with an executable")
+		}
+		return &commandPasswordSource{command: config.Command}, nil
+	}
+	if config.File != "" {
+		return &filePasswordSource{file: config.File}, nil
+	}
+	return &staticPasswordSource{password: password}, nil
+}
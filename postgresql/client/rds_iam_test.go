@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestRDSIAMTokenSourcePassword(t *testing.T) {
+	source := &rdsIAMTokenSource{
+		username: "iam_user",
+		region:   "eu-west-1",
+		credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}, nil
+		}),
+	}
+	token, err := source.Password(context.Background(), "mydb.123456789012.eu-west-1.rds.amazonaws.com", 5433)
+	if err != nil {
+		t.Fatalf("Password() error: %v", err)
+	}
+	if strings.Contains(token, "://") {
+		t.Fatalf("token %q must not contain a scheme", token)
+	}
+	u, err := url.Parse("https://" + token)
+	if err != nil {
+		t.Fatalf("token %q is not a URL: %v", token, err)
+	}
+	if got, want := u.Host, "mydb.123456789012.eu-west-1.rds.amazonaws.com:5433"; got != want {
+		t.Errorf("host = %q, want %q", got, want)
+	}
+	query := u.Query()
+	expected := map[string]string{
+		"Action":              "connect",
+		"DBUser":              "iam_user",
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Expires":       "900",
+		"X-Amz-SignedHeaders": "host",
+	}
+	for key, want := range expected {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if credential := query.Get("X-Amz-Credential"); !strings.HasPrefix(credential, "AKIDEXAMPLE/") || !strings.HasSuffix(credential, "/eu-west-1/rds-db/aws4_request") {
+		t.Errorf("X-Amz-Credential = %q, want AKIDEXAMPLE/<date>/eu-west-1/rds-db/aws4_request", credential)
+	}
+	if query.Get("X-Amz-Date") == "" {
+		t.Error("X-Amz-Date is missing")
+	}
+	if signature := query.Get("X-Amz-Signature"); len(signature) != 64 {
+		t.Errorf("X-Amz-Signature = %q, want 64 hex digits", signature)
+	}
+}
+
+func TestRDSIAMTokenSourcePasswordCredentialsError(t *testing.T) {
+	source := &rdsIAMTokenSource{
+		username: "iam_user",
+		region:   "eu-west-1",
+		credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{}, context.DeadlineExceeded
+		}),
+	}
+	_, err := source.Password(context.Background(), "localhost", 5432)
+	if err == nil {
+		t.Fatal("Password() succeeded without credentials")
+	}
+}
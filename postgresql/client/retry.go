@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+)
+
+const (
+	retryInitialBackoff = 100 * time.Millisecond
+	retryMaxBackoff     = 5 * time.Second
+)
+
+// RetryConfig limits how often and for how long transient errors are retried.  MaxAttempts includes the first
+// attempt, so 1 disables retries.
+type RetryConfig struct {
+	MaxAttempts int
+	Timeout     time.Duration
+}
+
+// retryableCodes are the SQLSTATEs of errors that usually succeed when simply tried again
+var retryableCodes = map[pq.ErrorCode]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"55P03": true, // lock_not_available
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// rollbackCodes are the SQLSTATEs of retryable errors that guarantee that the statement was rolled back
+var rollbackCodes = map[pq.ErrorCode]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"55P03": true, // lock_not_available
+	"57P03": true, // cannot_connect_now
+}
+
+// unsentError marks an error that occurred before the statement was sent to the server
+type unsentError struct {
+	err error
+}
+
+func (e *unsentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *unsentError) Unwrap() error {
+	return e.err
+}
+
+// isRetryableError reports whether err is transient
+func isRetryableError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return retryableCodes[pqErr.Code] || (pqErr.Code.Class() == "08")
+	}
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// isRetryableExecError reports whether a statement that failed with err can safely be run again.  After a lost
+// connection the statement may have been committed, and running non idempotent DDL again would then fail with an
+// error hiding that it succeeded, so only errors that guarantee a rollback are retried, or transient errors that
+// occurred before the statement was sent.
+func isRetryableExecError(err error) bool {
+	var unsent *unsentError
+	if errors.As(err, &unsent) {
+		return isRetryableError(unsent.err)
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return rollbackCodes[pqErr.Code]
+	}
+	// A refused connection never reached the server
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retry calls fn until it succeeds, fails with an error that is not retryable, or the attempts or time of the
+// RetryConfig are used up.  The delay between attempts grows exponentially with full jitter.
+func (c *Client) retry(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	start := time.Now()
+	backoff := retryInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if (err == nil) || !retryable(err) || (attempt >= c.retryConfig.MaxAttempts) {
+			return err
+		}
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if (c.retryConfig.Timeout > 0) && (time.Since(start)+delay > c.retryConfig.Timeout) {
+			return err
+		}
+		tflog.Warn(ctx, "PostgreSQL Retry:", map[string]any{"Attempt": attempt, "Delay": delay.String(), "Error": err.Error()})
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		backoff *= 2
+		if backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+	}
+}
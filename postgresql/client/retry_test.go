@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pq.Error{Code: "40001"}, true},
+		{"deadlock", &pq.Error{Code: "40P01"}, true},
+		{"too many connections", &pq.Error{Code: "53300"}, true},
+		{"lock not available", &pq.Error{Code: "55P03"}, true},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, true},
+		{"cannot connect now", &pq.Error{Code: "57P03"}, true},
+		{"connection failure", &pq.Error{Code: "08006"}, true},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"undefined table", &pq.Error{Code: "42P01"}, false},
+		{"bad connection", driver.ErrBadConn, true},
+		{"eof", io.EOF, true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, true},
+		{"broken pipe", &net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE}, true},
+		{"wrapped", fmt.Errorf("query: %w", &pq.Error{Code: "40001"}), true},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryableExecError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pq.Error{Code: "40001"}, true},
+		{"deadlock", &pq.Error{Code: "40P01"}, true},
+		{"lock not available", &pq.Error{Code: "55P03"}, true},
+		{"cannot connect now", &pq.Error{Code: "57P03"}, true},
+		{"admin shutdown may have committed", &pq.Error{Code: "57P01"}, false},
+		{"connection failure may have committed", &pq.Error{Code: "08006"}, false},
+		{"eof may have committed", io.EOF, false},
+		{"bad connection may have committed", driver.ErrBadConn, false},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"unsent eof", &unsentError{io.EOF}, true},
+		{"unsent connection failure", &unsentError{&pq.Error{Code: "08006"}}, true},
+		{"unsent other", &unsentError{errors.New("boom")}, false},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableExecError(tt.err); got != tt.want {
+				t.Errorf("isRetryableExecError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	transient := &pq.Error{Code: "40001"}
+	permanent := &pq.Error{Code: "23505"}
+	tests := []struct {
+		name        string
+		maxAttempts int
+		errs        []error
+		attempts    int
+		err         error
+	}{
+		{"success", 3, []error{nil}, 1, nil},
+		{"success after retry", 3, []error{transient, transient, nil}, 3, nil},
+		{"not retryable", 3, []error{permanent, nil}, 1, permanent},
+		{"attempts used up", 2, []error{transient, transient, nil}, 2, transient},
+		{"retries disabled", 1, []error{transient, nil}, 1, transient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{retryConfig: RetryConfig{MaxAttempts: tt.maxAttempts}}
+			attempts := 0
+			err := c.retry(context.Background(), isRetryableError, func() error {
+				attempts++
+				return tt.errs[attempts-1]
+			})
+			if err != tt.err {
+				t.Errorf("retry() error = %v, want %v", err, tt.err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("retry() made %d attempts, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryContextCancelled(t *testing.T) {
+	c := &Client{retryConfig: RetryConfig{MaxAttempts: 5}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	transient := &pq.Error{Code: "40001"}
+	attempts := 0
+	start := time.Now()
+	err := c.retry(ctx, isRetryableError, func() error {
+		attempts++
+		return transient
+	})
+	if err != transient {
+		t.Errorf("retry() error = %v, want %v", err, transient)
+	}
+	if attempts != 1 {
+		t.Errorf("retry() made %d attempts after cancellation, want 1", attempts)
+	}
+	if elapsed := time.Since(start); elapsed >= retryInitialBackoff {
+		t.Errorf("retry() waited %s after cancellation", elapsed)
+	}
+}
+
+func TestRetryTimeout(t *testing.T) {
+	c := &Client{retryConfig: RetryConfig{MaxAttempts: 5, Timeout: time.Millisecond}}
+	transient := &pq.Error{Code: "40001"}
+	attempts := 0
+	err := c.retry(context.Background(), isRetryableError, func() error {
+		attempts++
+		return transient
+	})
+	if err != transient {
+		t.Errorf("retry() error = %v, want %v", err, transient)
+	}
+	if attempts != 1 {
+		t.Errorf("retry() made %d attempts, want 1 since the backoff exceeds the timeout", attempts)
+	}
+}
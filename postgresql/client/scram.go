@@ -0,0 +1,131 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// Same as the scram_iterations default of the server
+	scramIterations = 4096
+	scramSaltLength = 16
+)
+
+var (
+	md5PasswordRegexp   = regexp.MustCompile(`^md5[0-9a-f]{32}$`)
+	scramPasswordRegexp = regexp.MustCompile(`^SCRAM-SHA-256\$\d+:[A-Za-z0-9+/=]+\$[A-Za-z0-9+/=]+:[A-Za-z0-9+/=]+$`)
+	// Matches password literals as produced by pq.QuoteLiteral, which may be prefixed with E when they contain
+	// backslashes
+	passwordLiteralRegexp = regexp.MustCompile(`(?i)(\bpassword\s+)E?'(?:[^']|'')*'`)
+)
+
+// IsPasswordHash tells whether password already is an MD5 or SCRAM-SHA-256 verifier, which the server stores as is
+func IsPasswordHash(password string) bool {
+	return md5PasswordRegexp.MatchString(password) || scramPasswordRegexp.MatchString(password)
+}
+
+// ScramSHA256Verifier computes the SCRAM-SHA-256 verifier the server would store for password, so that the
+// plaintext password never has to be sent.  Passwords that already are hashed are returned unchanged.
+func ScramSHA256Verifier(password string) (string, error) {
+	if IsPasswordHash(password) {
+		return password, nil
+	}
+	salt := make([]byte, scramSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", fmt.Errorf("Error generating SCRAM salt: %w", err)
+	}
+	return scramSHA256Verifier(password, salt, scramIterations)
+}
+
+// scramSHA256Verifier computes the SCRAM-SHA-256 verifier of password with a given salt and iteration count
+func scramSHA256Verifier(password string, salt []byte, iterations int) (string, error) {
+	saltedPassword, err := pbkdf2.Key(sha256.New, saslPrep(password), salt, iterations, sha256.Size)
+	if err != nil {
+		return "", fmt.Errorf("Error computing SCRAM salted password: %w", err)
+	}
+	clientKey := scramHMAC(saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	serverKey := scramHMAC(saltedPassword, "Server Key")
+	return fmt.Sprintf("SCRAM-SHA-256$%d:%s$%s:%s", iterations, base64.StdEncoding.EncodeToString(salt), base64.StdEncoding.EncodeToString(storedKey[:]), base64.StdEncoding.EncodeToString(serverKey)), nil
+}
+
+func scramHMAC(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// saslPrep normalizes password like the server does before hashing it (RFC 4013).  Like the server, the password is
+// used as is when it is pure ASCII or contains prohibited characters.  Unassigned code points and bidirectional
+// text are not checked.
+func saslPrep(password string) string {
+	ascii := true
+	for _, r := range password {
+		if r > unicode.MaxASCII {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return password
+	}
+	var mapped strings.Builder
+	for _, r := range password {
+		switch {
+		case isSASLMappedToNothing(r):
+		case isSASLNonASCIISpace(r):
+			mapped.WriteRune(' ')
+		default:
+			mapped.WriteRune(r)
+		}
+	}
+	normalized := norm.NFKC.String(mapped.String())
+	for _, r := range normalized {
+		if isSASLProhibited(r) {
+			return password
+		}
+	}
+	return normalized
+}
+
+func isSASLMappedToNothing(r rune) bool {
+	return (r == 0x00AD) || (r == 0x034F) || (r == 0x1806) || ((r >= 0x180B) && (r <= 0x180D)) || ((r >= 0x200B) && (r <= 0x200D)) || (r == 0x2060) || ((r >= 0xFE00) && (r <= 0xFE0F)) || (r == 0xFEFF)
+}
+
+func isSASLNonASCIISpace(r rune) bool {
+	return (r == 0x00A0) || (r == 0x1680) || ((r >= 0x2000) && (r <= 0x200A)) || (r == 0x202F) || (r == 0x205F) || (r == 0x3000)
+}
+
+func isSASLProhibited(r rune) bool {
+	switch {
+	case unicode.IsControl(r), unicode.Is(unicode.Co, r), unicode.Is(unicode.Cs, r):
+		return true
+	case (r >= 0xFDD0) && (r <= 0xFDEF), (r & 0xFFFE) == 0xFFFE:
+		// Non-character code points
+		return true
+	case (r >= 0xFFF9) && (r <= 0xFFFD), (r >= 0x2FF0) && (r <= 0x2FFB):
+		return true
+	case (r == 0x0340) || (r == 0x0341) || (r == 0x200E) || (r == 0x200F), (r >= 0x202A) && (r <= 0x202E), (r >= 0x206A) && (r <= 0x206F):
+		// Characters changing display properties
+		return true
+	case (r == 0xE0001), (r >= 0xE0020) && (r <= 0xE007F):
+		// Tagging characters
+		return true
+	}
+	return false
+}
+
+// RedactSQL replaces every password literal in query, so that it can be logged and shown in diagnostics
+func RedactSQL(query string) string {
+	return passwordLiteralRegexp.ReplaceAllString(query, "${1}'***'")
+}
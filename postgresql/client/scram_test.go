@@ -0,0 +1,141 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// The example exchange of RFC 7677 section 3, for user "user" with password "pencil"
+const (
+	rfc7677Salt             = "W22ZaJ0SNY7soEsUEjb6gQ=="
+	rfc7677Iterations       = 4096
+	rfc7677AuthMessage      = "n=user,r=rOprNGfwEbeRWgbNEkqO,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096,c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+	rfc7677ClientProof      = "dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	rfc7677ServerSignature  = "6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="
+	rfc7677ExpectedVerifier = "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU="
+)
+
+func TestScramSHA256VerifierRFC7677(t *testing.T) {
+	salt, err := base64.StdEncoding.DecodeString(rfc7677Salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := scramSHA256Verifier("pencil", salt, rfc7677Iterations)
+	if err != nil {
+		t.Fatalf("scramSHA256Verifier() error: %v", err)
+	}
+	if verifier != rfc7677ExpectedVerifier {
+		t.Fatalf("verifier = %q, want %q", verifier, rfc7677ExpectedVerifier)
+	}
+	// A server storing the verifier must accept the client proof of the RFC and produce its server signature
+	keys := strings.SplitN(verifier[strings.LastIndex(verifier, "$")+1:], ":", 2)
+	storedKey, _ := base64.StdEncoding.DecodeString(keys[0])
+	serverKey, _ := base64.StdEncoding.DecodeString(keys[1])
+	if got := base64.StdEncoding.EncodeToString(scramHMAC(serverKey, rfc7677AuthMessage)); got != rfc7677ServerSignature {
+		t.Errorf("server signature = %q, want %q", got, rfc7677ServerSignature)
+	}
+	proof, _ := base64.StdEncoding.DecodeString(rfc7677ClientProof)
+	clientSignature := scramHMAC(storedKey, rfc7677AuthMessage)
+	clientKey := make([]byte, len(proof))
+	for i := range proof {
+		clientKey[i] = proof[i] ^ clientSignature[i]
+	}
+	if sum := sha256.Sum256(clientKey); !hmac.Equal(sum[:], storedKey) {
+		t.Error("client proof does not match the stored key")
+	}
+}
+
+func TestScramSHA256Verifier(t *testing.T) {
+	first, err := ScramSHA256Verifier("pencil")
+	if err != nil {
+		t.Fatalf("ScramSHA256Verifier() error: %v", err)
+	}
+	if !IsPasswordHash(first) {
+		t.Errorf("verifier %q is not recognized as a password hash", first)
+	}
+	if !strings.HasPrefix(first, "SCRAM-SHA-256$4096:") {
+		t.Errorf("verifier %q does not use %d iterations", first, scramIterations)
+	}
+	second, err := ScramSHA256Verifier("pencil")
+	if err != nil {
+		t.Fatalf("ScramSHA256Verifier() error: %v", err)
+	}
+	if first == second {
+		t.Error("verifiers of the same password share a salt")
+	}
+	for _, hash := range []string{rfc7677ExpectedVerifier, "md5" + strings.Repeat("0123456789abcdef", 2)} {
+		got, err := ScramSHA256Verifier(hash)
+		if err != nil {
+			t.Fatalf("ScramSHA256Verifier(%q) error: %v", hash, err)
+		}
+		if got != hash {
+			t.Errorf("ScramSHA256Verifier(%q) = %q, want it unchanged", hash, got)
+		}
+	}
+}
+
+func TestSASLPrep(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"ascii", "pencil", "pencil"},
+		{"ascii control characters are kept", "pen\x07cil", "pen\x07cil"},
+		{"ascii is not normalized", "Pencil ", "Pencil "},
+		{"non-ascii space", "I\u00a0X", "I X"},
+		{"ideographic space", "I\u3000X", "I X"},
+		{"soft hyphen mapped to nothing", "I\u00adX", "IX"},
+		{"zero width space mapped to nothing", "I\u200bX", "IX"},
+		{"nfkc compatibility", "\u00aa", "a"},
+		{"nfkc roman numeral", "\u2168", "IX"},
+		{"nfkc composition", "e\u0301", "\u00e9"},
+		{"already normalized", "p\u00e9ncil", "p\u00e9ncil"},
+		{"prohibited control character", "p\u00e9n\u0085cil", "p\u00e9n\u0085cil"},
+		{"prohibited private use", "\ue000\u00e9", "\ue000\u00e9"},
+		{"prohibited bidi mark", "\u00e9\u200e", "\u00e9\u200e"},
+		{"prohibited non-character", "\u00e9\uffff", "\u00e9\uffff"},
+		{"prohibited tagging character", "\u00e9\U000e0041", "\u00e9\U000e0041"},
+		{"prohibited after mapping keeps the original", "\u00aa\u00a0\u0007", "\u00aa\u00a0\u0007"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := saslPrep(tt.password); got != tt.want {
+				t.Errorf("saslPrep(%q) = %q, want %q", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"plain literal", `alter role "MyRole" password 'secret'`, `alter role "MyRole" password '***'`},
+		{"upper case", `CREATE ROLE "MyRole" LOGIN PASSWORD 'secret'`, `CREATE ROLE "MyRole" LOGIN PASSWORD '***'`},
+		{"escape string literal", `alter role "MyRole" password  E'back\\slash'`, `alter role "MyRole" password  '***'`},
+		{"lower case escape prefix", `alter role "MyRole" password e'back\\slash'`, `alter role "MyRole" password '***'`},
+		{"doubled quotes", `alter role "MyRole" password 'it''s' valid until 'infinity'`, `alter role "MyRole" password '***' valid until 'infinity'`},
+		{"only doubled quotes", `alter role "MyRole" password ''''''`, `alter role "MyRole" password '***'`},
+		{"empty literal", `alter role "MyRole" password ''`, `alter role "MyRole" password '***'`},
+		{"password null", `alter role "MyRole" password null`, `alter role "MyRole" password null`},
+		{"encrypted password", `create role "MyRole" encrypted password 'secret' login`, `create role "MyRole" encrypted password '***' login`},
+		{"several passwords", `alter role "a" password 'x'; alter role "b" password 'y'`, `alter role "a" password '***'; alter role "b" password '***'`},
+		{"newline before literal", "alter role \"MyRole\" password\n'secret'", "alter role \"MyRole\" password\n'***'"},
+		{"no password", `select 'password'`, `select 'password'`},
+		{"identifier ending in password", `alter role "MyRole" set mypassword 'x'`, `alter role "MyRole" set mypassword 'x'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactSQL(tt.query); got != tt.want {
+				t.Errorf("RedactSQL(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHJumpHost is an intermediate bastion the tunnel hops through.  An empty PrivateKey reuses the key of the tunnel.
+type SSHJumpHost struct {
+	Host       string
+	Port       int
+	User       string
+	PrivateKey string
+}
+
+// SSHTunnelConfig configures an SSH tunnel all database connections are routed through.  PrivateKey may be inline
+// PEM or a file path, KnownHosts may be inline known_hosts content or a file path and defaults to
+// ~/.ssh/known_hosts.  JumpHosts are traversed in order before connecting to Host.
+type SSHTunnelConfig struct {
+	Host                 string
+	Port                 int
+	User                 string
+	PrivateKey           string
+	PrivateKeyPassphrase string
+	KnownHosts           string
+	JumpHosts            []SSHJumpHost
+}
+
+type sshHop struct {
+	address      string
+	clientConfig *ssh.ClientConfig
+}
+
+// sshTunnel lazily opens one SSH connection (through any jump hosts) that is shared by every *sql.DB of a Client
+type sshTunnel struct {
+	hops    []sshHop
+	mu      sync.Mutex
+	clients []*ssh.Client
+}
+
+func newSSHTunnel(config SSHTunnelConfig) (*sshTunnel, error) {
+	hostKeyCallback, err := loadKnownHosts(config.KnownHosts)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := loadSSHSigner(config.PrivateKey, config.PrivateKeyPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	t := &sshTunnel{}
+	for _, jumpHost := range config.JumpHosts {
+		jumpSigner := signer
+		if jumpHost.PrivateKey != "" {
+			jumpSigner, err = loadSSHSigner(jumpHost.PrivateKey, config.PrivateKeyPassphrase)
+			if err != nil {
+				return nil, err
+			}
+		}
+		t.hops = append(t.hops, newSSHHop(jumpHost.Host, jumpHost.Port, jumpHost.User, jumpSigner, hostKeyCallback))
+	}
+	t.hops = append(t.hops, newSSHHop(config.Host, config.Port, config.User, signer, hostKeyCallback))
+	return t, nil
+}
+
+func newSSHHop(host string, port int, user string, signer ssh.Signer, hostKeyCallback ssh.HostKeyCallback) sshHop {
+	if port == 0 {
+		port = 22
+	}
+	return sshHop{
+		address: net.JoinHostPort(host, strconv.Itoa(port)),
+		clientConfig: &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+		},
+	}
+}
+
+func loadSSHSigner(privateKey string, passphrase string) (ssh.Signer, error) {
+	key, err := loadPEM(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	var signer ssh.Signer
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing ssh private key: %w", err)
+	}
+	return signer, nil
+}
+
+// loadKnownHosts accepts a file path or inline known_hosts content.  The value is tried as a path first, so that
+// paths containing spaces work, and only read as content when no such file exists and it looks like host key entries.
+// knownhosts only reads files, so inline content is written to a temporary file that is removed again once parsed.
+func loadKnownHosts(knownHosts string) (ssh.HostKeyCallback, error) {
+	if knownHosts == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("known_hosts must be specified when there is no home directory: %w", err)
+		}
+		knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	_, statErr := os.Stat(knownHosts)
+	if (statErr == nil) || !strings.ContainsAny(knownHosts, " \t\n") {
+		callback, err := knownhosts.New(knownHosts)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading known_hosts file %s: %w", knownHosts, err)
+		}
+		return callback, nil
+	}
+	file, err := os.CreateTemp("", "known_hosts")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(knownHosts)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	callback, err := knownhosts.New(file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing known_hosts, which is neither an existing file (%v) nor valid known_hosts content: %w", statErr, err)
+	}
+	return callback, nil
+}
+
+// connect returns the SSH client of the last hop, establishing the chain of hops if needed
+func (t *sshTunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.clients) == len(t.hops) {
+		return t.clients[len(t.clients)-1], nil
+	}
+	var dialer net.Dialer
+	for _, hop := range t.hops {
+		var conn net.Conn
+		var err error
+		if len(t.clients) == 0 {
+			conn, err = dialer.DialContext(ctx, "tcp", hop.address)
+		} else {
+			conn, err = t.clients[len(t.clients)-1].DialContext(ctx, "tcp", hop.address)
+		}
+		if err != nil {
+			t.closeLocked()
+			return nil, fmt.Errorf("Error connecting to ssh host %s: %w", hop.address, err)
+		}
+		sshConn, chans, reqs, err := ssh.NewClientConn(conn, hop.address, hop.clientConfig)
+		if err != nil {
+			conn.Close()
+			t.closeLocked()
+			return nil, fmt.Errorf("Error establishing ssh connection to %s: %w", hop.address, err)
+		}
+		t.clients = append(t.clients, ssh.NewClient(sshConn, chans, reqs))
+	}
+	return t.clients[len(t.clients)-1], nil
+}
+
+func (t *sshTunnel) closeLocked() {
+	for i := len(t.clients) - 1; i >= 0; i-- {
+		t.clients[i].Close()
+	}
+	t.clients = nil
+}
+
+// reset drops client if it is still the current tunnel so that the next dial reconnects
+func (t *sshTunnel) reset(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if (len(t.clients) > 0) && (t.clients[len(t.clients)-1] == client) {
+		t.closeLocked()
+	}
+}
+
+// DialContext opens a connection to address as seen from the last hop.  A broken tunnel is re-established once.
+func (t *sshTunnel) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		client, err := t.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := client.DialContext(ctx, network, address)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) {
+			// The tunnel works, the target is just unreachable from it
+			break
+		}
+		t.reset(client)
+	}
+	return nil, fmt.Errorf("Error connecting to %s through ssh tunnel: %w", address, lastErr)
+}
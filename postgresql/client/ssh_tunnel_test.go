@@ -0,0 +1,390 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is an in-process SSH server that forwards direct-tcpip channels, like a bastion does
+type testSSHServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+	mu       sync.Mutex
+	conns    []net.Conn
+	accepted int
+	targets  []string
+}
+
+func newTestSSHServer(t *testing.T, hostKey ssh.Signer, authorizedKey ssh.PublicKey) *testSSHServer {
+	t.Helper()
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if (conn.User() != "tunnel") || (string(key.Marshal()) != string(authorizedKey.Marshal())) {
+				return nil, errors.New("unauthorized")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSSHServer{listener: listener, config: config}
+	t.Cleanup(func() {
+		listener.Close()
+		s.closeConns()
+	})
+	go s.serve()
+	return s
+}
+
+func (s *testSSHServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.accepted++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *testSSHServer) handle(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "only direct-tcpip is supported")
+			continue
+		}
+		var payload struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+			newChannel.Reject(ssh.Prohibited, err.Error())
+			continue
+		}
+		target := net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port)))
+		s.mu.Lock()
+		s.targets = append(s.targets, target)
+		s.mu.Unlock()
+		targetConn, err := net.Dial("tcp", target)
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, channelReqs, err := newChannel.Accept()
+		if err != nil {
+			targetConn.Close()
+			continue
+		}
+		go ssh.DiscardRequests(channelReqs)
+		go func() {
+			io.Copy(channel, targetConn)
+			channel.Close()
+		}()
+		go func() {
+			io.Copy(targetConn, channel)
+			targetConn.Close()
+		}()
+	}
+}
+
+// closeConns drops every SSH connection, as a restarted bastion or an idle timeout would
+func (s *testSSHServer) closeConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testSSHServer) stats() (int, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accepted, append([]string(nil), s.targets...)
+}
+
+func (s *testSSHServer) hostPort() (string, int) {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+// newEchoServer returns the address of a TCP server that echoes what it receives
+func newEchoServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func assertEcho(t *testing.T, conn net.Conn) {
+	t.Helper()
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("echo = %q, want %q", buf, "ping")
+	}
+}
+
+type testSSHEnv struct {
+	hostKey       ssh.Signer
+	clientKey     ssh.Signer
+	clientKeyPEM  string
+	knownHostsFor func(servers ...*testSSHServer) string
+}
+
+func newTestSSHEnv(t *testing.T) testSSHEnv {
+	t.Helper()
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientKey, err := ssh.NewSignerFromKey(clientPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSSHEnv{
+		hostKey:      hostKey,
+		clientKey:    clientKey,
+		clientKeyPEM: string(pem.EncodeToMemory(block)),
+		knownHostsFor: func(servers ...*testSSHServer) string {
+			var addresses []string
+			for _, server := range servers {
+				addresses = append(addresses, server.listener.Addr().String())
+			}
+			return knownhosts.Line(addresses, hostKey.PublicKey()) + "\n"
+		},
+	}
+}
+
+func TestSSHTunnelJumpHosts(t *testing.T) {
+	env := newTestSSHEnv(t)
+	jump := newTestSSHServer(t, env.hostKey, env.clientKey.PublicKey())
+	bastion := newTestSSHServer(t, env.hostKey, env.clientKey.PublicKey())
+	echo := newEchoServer(t)
+	jumpHost, jumpPort := jump.hostPort()
+	bastionHost, bastionPort := bastion.hostPort()
+	tunnel, err := newSSHTunnel(SSHTunnelConfig{
+		Host:       bastionHost,
+		Port:       bastionPort,
+		User:       "tunnel",
+		PrivateKey: env.clientKeyPEM,
+		KnownHosts: env.knownHostsFor(jump, bastion),
+		JumpHosts:  []SSHJumpHost{{Host: jumpHost, Port: jumpPort, User: "tunnel"}},
+	})
+	if err != nil {
+		t.Fatalf("newSSHTunnel() error: %v", err)
+	}
+	conn, err := tunnel.DialContext(context.Background(), "tcp", echo)
+	if err != nil {
+		t.Fatalf("DialContext() error: %v", err)
+	}
+	assertEcho(t, conn)
+	jumpAccepted, jumpTargets := jump.stats()
+	if (jumpAccepted != 1) || (len(jumpTargets) != 1) || (jumpTargets[0] != bastion.listener.Addr().String()) {
+		t.Errorf("jump host accepted %d connections and forwarded to %v, want 1 connection forwarded to the bastion", jumpAccepted, jumpTargets)
+	}
+	bastionAccepted, bastionTargets := bastion.stats()
+	if (bastionAccepted != 1) || (len(bastionTargets) != 1) || (bastionTargets[0] != echo) {
+		t.Errorf("bastion accepted %d connections and forwarded to %v, want 1 connection forwarded to %s", bastionAccepted, bastionTargets, echo)
+	}
+	// Further connections share the tunnel
+	conn, err = tunnel.DialContext(context.Background(), "tcp", echo)
+	if err != nil {
+		t.Fatalf("DialContext() error: %v", err)
+	}
+	assertEcho(t, conn)
+	if accepted, _ := jump.stats(); accepted != 1 {
+		t.Errorf("jump host accepted %d connections, want the tunnel to be reused", accepted)
+	}
+}
+
+func TestSSHTunnelReconnect(t *testing.T) {
+	env := newTestSSHEnv(t)
+	jump := newTestSSHServer(t, env.hostKey, env.clientKey.PublicKey())
+	bastion := newTestSSHServer(t, env.hostKey, env.clientKey.PublicKey())
+	echo := newEchoServer(t)
+	jumpHost, jumpPort := jump.hostPort()
+	bastionHost, bastionPort := bastion.hostPort()
+	tunnel, err := newSSHTunnel(SSHTunnelConfig{
+		Host:       bastionHost,
+		Port:       bastionPort,
+		User:       "tunnel",
+		PrivateKey: env.clientKeyPEM,
+		KnownHosts: env.knownHostsFor(jump, bastion),
+		JumpHosts:  []SSHJumpHost{{Host: jumpHost, Port: jumpPort, User: "tunnel"}},
+	})
+	if err != nil {
+		t.Fatalf("newSSHTunnel() error: %v", err)
+	}
+	conn, err := tunnel.DialContext(context.Background(), "tcp", echo)
+	if err != nil {
+		t.Fatalf("DialContext() error: %v", err)
+	}
+	assertEcho(t, conn)
+	jump.closeConns()
+	bastion.closeConns()
+	conn, err = tunnel.DialContext(context.Background(), "tcp", echo)
+	if err != nil {
+		t.Fatalf("DialContext() after the tunnel broke error: %v", err)
+	}
+	assertEcho(t, conn)
+	if accepted, _ := jump.stats(); accepted != 2 {
+		t.Errorf("jump host accepted %d connections, want 2", accepted)
+	}
+	if accepted, _ := bastion.stats(); accepted != 2 {
+		t.Errorf("bastion accepted %d connections, want 2", accepted)
+	}
+}
+
+func TestSSHTunnelUnreachableTarget(t *testing.T) {
+	env := newTestSSHEnv(t)
+	bastion := newTestSSHServer(t, env.hostKey, env.clientKey.PublicKey())
+	bastionHost, bastionPort := bastion.hostPort()
+	tunnel, err := newSSHTunnel(SSHTunnelConfig{
+		Host:       bastionHost,
+		Port:       bastionPort,
+		User:       "tunnel",
+		PrivateKey: env.clientKeyPEM,
+		KnownHosts: env.knownHostsFor(bastion),
+	})
+	if err != nil {
+		t.Fatalf("newSSHTunnel() error: %v", err)
+	}
+	// Reserve a port and free it again so that nothing listens on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := listener.Addr().String()
+	listener.Close()
+	_, err = tunnel.DialContext(context.Background(), "tcp", target)
+	var openErr *ssh.OpenChannelError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("DialContext() error = %v, want *ssh.OpenChannelError", err)
+	}
+	if accepted, _ := bastion.stats(); accepted != 1 {
+		t.Errorf("bastion accepted %d connections, want the working tunnel to be kept", accepted)
+	}
+}
+
+func TestSSHTunnelUnknownHostKey(t *testing.T) {
+	env := newTestSSHEnv(t)
+	other := newTestSSHEnv(t)
+	bastion := newTestSSHServer(t, other.hostKey, env.clientKey.PublicKey())
+	bastionHost, bastionPort := bastion.hostPort()
+	tunnel, err := newSSHTunnel(SSHTunnelConfig{
+		Host:       bastionHost,
+		Port:       bastionPort,
+		User:       "tunnel",
+		PrivateKey: env.clientKeyPEM,
+		KnownHosts: env.knownHostsFor(bastion),
+	})
+	if err != nil {
+		t.Fatalf("newSSHTunnel() error: %v", err)
+	}
+	_, err = tunnel.DialContext(context.Background(), "tcp", "127.0.0.1:5432")
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		t.Fatalf("DialContext() error = %v, want *knownhosts.KeyError", err)
+	}
+}
+
+func TestLoadKnownHosts(t *testing.T) {
+	env := newTestSSHEnv(t)
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2222}
+	content := knownhosts.Line([]string{addr.String()}, env.hostKey.PublicKey()) + "\n"
+	dir := filepath.Join(t.TempDir(), "My Keys")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "known_hosts")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		knownHosts string
+	}{
+		{"path", path},
+		{"inline", content},
+		{"inline without trailing newline", content[:len(content)-1]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, err := loadKnownHosts(tt.knownHosts)
+			if err != nil {
+				t.Fatalf("loadKnownHosts() error: %v", err)
+			}
+			if err := callback(addr.String(), addr, env.hostKey.PublicKey()); err != nil {
+				t.Errorf("callback() of the known host key error: %v", err)
+			}
+			if err := callback(addr.String(), addr, env.clientKey.PublicKey()); err == nil {
+				t.Error("callback() of another key succeeded")
+			}
+		})
+	}
+	_, err := loadKnownHosts(filepath.Join(dir, "missing"))
+	if err == nil {
+		t.Error("loadKnownHosts() of a missing file succeeded")
+	}
+}
@@ -0,0 +1,311 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+const (
+	SSLModeDisable    = "disable"
+	SSLModeAllow      = "allow"
+	SSLModePrefer     = "prefer"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
+
+	// Magic number PostgreSQL expects in an SSLRequest packet
+	sslRequestCode = 80877103
+)
+
+// SSLConfig holds the TLS settings of the provider.  RootCert, Cert and Key may either be inline PEM or a path to
+// a PEM file.  An empty Mode leaves TLS negotiation to the driver defaults (including PGSSLMODE and friends).
+type SSLConfig struct {
+	Mode        string
+	RootCert    string
+	Cert        string
+	Key         string
+	KeyPassword string
+}
+
+// loadPEM returns value if it already is inline PEM, otherwise value is treated as a file path and read
+func loadPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading PEM file %s: %w", value, err)
+	}
+	return data, nil
+}
+
+// decryptKey decrypts an encrypted PKCS#8 or legacy encrypted PEM private key with password.  Unencrypted keys are
+// returned as is.
+func decryptKey(keyPEM []byte, password string) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("sslkey does not contain a PEM block")
+	}
+	encrypted := block.Type == "ENCRYPTED PRIVATE KEY"
+	if !encrypted && !x509.IsEncryptedPEMBlock(block) {
+		return keyPEM, nil
+	}
+	if password == "" {
+		return nil, errors.New("sslkey is encrypted but no sslpassword was provided")
+	}
+	if encrypted {
+		der, err := decryptPKCS8(block.Bytes, []byte(password))
+		if err != nil {
+			return nil, fmt.Errorf("Error decrypting sslkey: %w", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+	// The legacy format is insecure by design, which is why the standard library deprecated it, but it is still
+	// what older versions of openssl produce
+	der, err := x509.DecryptPEMBlock(block, []byte(password))
+	if err != nil {
+		return nil, fmt.Errorf("Error decrypting sslkey: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+}
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// encryptedPrivateKeyInfo and the types below are the ASN.1 structures of an encrypted PKCS#8 key (RFC 5958) using
+// PBES2 (RFC 8018)
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPKCS8 decrypts the DER of an encrypted PKCS#8 key as produced by current versions of openssl, which use
+// PBES2 with PBKDF2 and AES-CBC
+func decryptPKCS8(der []byte, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	_, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted PKCS#8 key: %w", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported PKCS#8 encryption algorithm %s, only PBES2 is supported", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	_, err = asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PBES2 parameters: %w", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported PBES2 key derivation function %s, only PBKDF2 is supported", params.KeyDerivationFunc.Algorithm)
+	}
+	var kdfParams pbkdf2Params
+	_, err = asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PBKDF2 parameters: %w", err)
+	}
+	var prf func() hash.Hash
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA384):
+		prf = sha512.New384
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA512):
+		prf = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported PBKDF2 pseudorandom function %s", kdfParams.PRF.Algorithm)
+	}
+	var keyLength int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLength = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLength = 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLength = 32
+	default:
+		return nil, fmt.Errorf("unsupported PBES2 encryption scheme %s, only AES-CBC is supported", params.EncryptionScheme.Algorithm)
+	}
+	var iv []byte
+	_, err = asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv)
+	if (err != nil) || (len(iv) != aes.BlockSize) {
+		return nil, errors.New("invalid AES-CBC initialization vector")
+	}
+	if (len(info.EncryptedData) == 0) || (len(info.EncryptedData)%aes.BlockSize != 0) {
+		return nil, errors.New("invalid length of encrypted PKCS#8 key")
+	}
+	key, err := pbkdf2.Key(prf, string(password), kdfParams.Salt, kdfParams.IterationCount, keyLength)
+	if err != nil {
+		return nil, err
+	}
+	aesCipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(aesCipher, iv).CryptBlocks(plain, info.EncryptedData)
+	// A wrong password almost always shows up as invalid padding, otherwise as a key that cannot be parsed
+	padding := int(plain[len(plain)-1])
+	if (padding == 0) || (padding > aes.BlockSize) || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("incorrect sslpassword")
+	}
+	plain = plain[:len(plain)-padding]
+	_, err = x509.ParsePKCS8PrivateKey(plain)
+	if err != nil {
+		return nil, errors.New("incorrect sslpassword")
+	}
+	return plain, nil
+}
+
+// newTLSConfig builds the tls.Config matching config.  ServerName is filled in per connection by pqDialer.
+func newTLSConfig(config SSLConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if config.RootCert != "" {
+		rootCert, err := loadPEM(config.RootCert)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(rootCert) {
+			return nil, errors.New("sslrootcert does not contain any PEM certificates")
+		}
+	}
+	if (config.Cert != "") != (config.Key != "") {
+		return nil, errors.New("sslcert and sslkey must be specified together")
+	}
+	if config.Cert != "" {
+		cert, err := loadPEM(config.Cert)
+		if err != nil {
+			return nil, err
+		}
+		key, err := loadPEM(config.Key)
+		if err != nil {
+			return nil, err
+		}
+		key, err = decryptKey(key, config.KeyPassword)
+		if err != nil {
+			return nil, err
+		}
+		keyPair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading sslcert/sslkey: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{keyPair}
+	}
+	switch config.Mode {
+	case SSLModeAllow, SSLModePrefer:
+		// Like libpq, the server certificate is never verified when TLS is optional
+		tlsConfig.InsecureSkipVerify = true
+	case SSLModeRequire:
+		// Like libpq, require only verifies the server certificate when a root certificate is given
+		tlsConfig.InsecureSkipVerify = true
+		if tlsConfig.RootCAs != nil {
+			tlsConfig.VerifyConnection = verifyCertificateAuthority(tlsConfig)
+		}
+	case SSLModeVerifyCA:
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = verifyCertificateAuthority(tlsConfig)
+	case SSLModeVerifyFull:
+	default:
+		return nil, fmt.Errorf("unsupported sslmode %q", config.Mode)
+	}
+	return tlsConfig, nil
+}
+
+// verifyCertificateAuthority checks the server certificate chain without checking the host name
+func verifyCertificateAuthority(tlsConfig *tls.Config) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("server did not present a certificate")
+		}
+		opts := x509.VerifyOptions{
+			Roots:         tlsConfig.RootCAs,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range state.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := state.PeerCertificates[0].Verify(opts)
+		return err
+	}
+}
+
+// startTLS sends an SSLRequest over conn and upgrades it to TLS if the server accepts.  Unless TLS is optional,
+// a server refusing TLS is an error, otherwise conn is returned to continue unencrypted.
+func startTLS(ctx context.Context, conn net.Conn, tlsConfig *tls.Config, optional bool, address string) (net.Conn, error) {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], sslRequestCode)
+	_, err := conn.Write(request)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]byte, 1)
+	_, err = io.ReadFull(conn, response)
+	if err != nil {
+		return nil, err
+	}
+	if (response[0] == 'N') && optional {
+		return conn, nil
+	}
+	if response[0] != 'S' {
+		return nil, errors.New("server does not support SSL, but SSL was required")
+	}
+	config := tlsConfig.Clone()
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			host = address
+		}
+		config.ServerName = host
+	}
+	// Accept renegotiation requests initiated by older servers
+	config.Renegotiation = tls.RenegotiateFreelyAsClient
+	tlsConn := tls.Client(conn, config)
+	err = tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tlsConn, nil
+}
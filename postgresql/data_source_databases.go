@@ -2,6 +2,8 @@ package postgresql
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -36,33 +38,57 @@ func dataSourceDatabases() *schema.Resource {
 	}
 }
 
+// listDatabasesQuery selects the names of the databases, including the templates if template is set and leaving
+// out the databases that do not allow connections if connectable is set
+func listDatabasesQuery(template bool, connectable bool) string {
+	conditions := []string{}
+	if !template {
+		conditions = append(conditions, "datistemplate = false")
+	}
+	if connectable {
+		conditions = append(conditions, "datallowconn")
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "where " + strings.Join(conditions, " and ") + " "
+	}
+	return fmt.Sprintf("select datname from pg_catalog.pg_database %sorder by datname", whereClause)
+}
+
+// listDatabases lists the names of the databases selected by listDatabasesQuery
+func listDatabases(ctx context.Context, c *client.Client, template bool, connectable bool) (string, []string, error) {
+	query, rows, err := c.Query(ctx, "", listDatabasesQuery(template, connectable))
+	if err != nil {
+		return query, nil, err
+	}
+	defer rows.Close()
+	names := []string{}
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return query, nil, err
+		}
+		names = append(names, name)
+	}
+	return query, names, rows.Err()
+}
+
 func dataSourceDatabasesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*client.Client)
-	template := d.Get("template").(bool)
-	templateClause := ""
-	if !template {
-		templateClause = "where datistemplate = false"
-	}
 	exclude, ok := d.GetOk("exclude")
 	excludeSet := schema.NewSet(schema.HashString, []interface{}{})
 	if ok {
 		excludeSet = exclude.(*schema.Set)
 	}
-	query, rows, err := c.Query(ctx, "", "select datname from pg_catalog.pg_database %s order by datname", templateClause)
+	query, databases, err := listDatabases(ctx, c, d.Get("template").(bool), false)
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
 	}
-	defer rows.Close()
 	names := []string{}
-	for rows.Next() {
-		var name string
-		err = rows.Scan(&name)
-		if err != nil {
-			d.SetId("")
-			return diag.FromErr(err)
-		}
+	for _, name := range databases {
 		if excludeSet.Contains(name) {
 			continue
 		}
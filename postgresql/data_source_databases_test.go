@@ -0,0 +1,24 @@
+package postgresql
+
+import "testing"
+
+func TestListDatabasesQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    bool
+		connectable bool
+		want        string
+	}{
+		{"all", true, false, "select datname from pg_catalog.pg_database order by datname"},
+		{"without templates", false, false, "select datname from pg_catalog.pg_database where datistemplate = false order by datname"},
+		{"connectable", true, true, "select datname from pg_catalog.pg_database where datallowconn order by datname"},
+		{"connectable without templates", false, true, "select datname from pg_catalog.pg_database where datistemplate = false and datallowconn order by datname"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listDatabasesQuery(tt.template, tt.connectable); got != tt.want {
+				t.Errorf("listDatabasesQuery(%v, %v) = %q, want %q", tt.template, tt.connectable, got, tt.want)
+			}
+		})
+	}
+}
@@ -64,7 +64,7 @@ func dataSourceRoutinesRead(ctx context.Context, d *schema.ResourceData, m inter
 	if ok {
 		excludeSet = exclude.(*schema.Set)
 	}
-	query, rows, err := c.Query(ctx, database, "select proname from pg_catalog.pg_proc where pronamespace::regnamespace = '%s'::regnamespace %s order by proname", schemaName, typeClause)
+	query, rows, err := c.Query(ctx, database, fmt.Sprintf("select proname from pg_catalog.pg_proc where pronamespace = (select oid from pg_catalog.pg_namespace where nspname = $1) %s order by proname", typeClause), schemaName)
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
@@ -2,6 +2,7 @@ package postgresql
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -54,7 +55,7 @@ func dataSourceSchemasRead(ctx context.Context, d *schema.ResourceData, m interf
 	if ok {
 		excludeSet = exclude.(*schema.Set)
 	}
-	query, rows, err := c.Query(ctx, database, "select schema_name from information_schema.schemata %s order by schema_name", systemClause)
+	query, rows, err := c.Query(ctx, database, fmt.Sprintf("select schema_name from information_schema.schemata %s order by schema_name", systemClause))
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
@@ -50,7 +50,7 @@ func dataSourceSequencesRead(ctx context.Context, d *schema.ResourceData, m inte
 	if ok {
 		excludeSet = exclude.(*schema.Set)
 	}
-	query, rows, err := c.Query(ctx, database, "select sequencename from pg_catalog.pg_sequences where schemaname = '%s' order by sequencename", schemaName)
+	query, rows, err := c.Query(ctx, database, "select sequencename from pg_catalog.pg_sequences where schemaname = $1 order by sequencename", schemaName)
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
@@ -50,7 +50,7 @@ func dataSourceTablesRead(ctx context.Context, d *schema.ResourceData, m interfa
 	if ok {
 		excludeSet = exclude.(*schema.Set)
 	}
-	query, rows, err := c.Query(ctx, database, "select tablename from pg_catalog.pg_tables where schemaname = '%s' order by tablename", schemaName)
+	query, rows, err := c.Query(ctx, database, "select tablename from pg_catalog.pg_tables where schemaname = $1 order by tablename", schemaName)
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
@@ -50,7 +50,7 @@ func dataSourceViewsRead(ctx context.Context, d *schema.ResourceData, m interfac
 	if ok {
 		excludeSet = exclude.(*schema.Set)
 	}
-	query, rows, err := c.Query(ctx, database, "select viewname from pg_catalog.pg_views where schemaname = '%s' order by viewname", schemaName)
+	query, rows, err := c.Query(ctx, database, "select viewname from pg_catalog.pg_views where schemaname = $1 order by viewname", schemaName)
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
@@ -0,0 +1,191 @@
+package postgresql
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/scastria/terraform-provider-postgresql/postgresql/client"
+)
+
+const (
+	ephemeralPasswordTypeName      = "postgresql_password"
+	ephemeralPasswordDefaultLength = 32
+	ephemeralPasswordMinLength     = 8
+	ephemeralPasswordMaxLength     = 1024
+
+	passwordAlphanumeric = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	// Leaves out quotes and backslashes, which are often mangled by the tools passwords are handed to
+	passwordSpecial = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+)
+
+var ephemeralPasswordType = tftypes.Object{
+	AttributeTypes: map[string]tftypes.Type{
+		"length":         tftypes.Number,
+		"special":        tftypes.Bool,
+		"result":         tftypes.String,
+		"scram_verifier": tftypes.String,
+	},
+}
+
+// ephemeralPasswordSchema describes the postgresql_password ephemeral resource.  Ephemeral resources are not
+// supported by the SDK, so the schema is written in protocol terms and served by grpcProviderServer.
+func ephemeralPasswordSchema() *tfprotov5.Schema {
+	return &tfprotov5.Schema{
+		Block: &tfprotov5.SchemaBlock{
+			Description: "Generates a random password that is never stored in plan or state.",
+			Attributes: []*tfprotov5.SchemaAttribute{
+				{
+					Name:        "length",
+					Type:        tftypes.Number,
+					Description: fmt.Sprintf("The number of characters of the password. Default: %d.", ephemeralPasswordDefaultLength),
+					Optional:    true,
+					Computed:    true,
+				},
+				{
+					Name:        "special",
+					Type:        tftypes.Bool,
+					Description: "Whether to include special characters in the password. Default: true.",
+					Optional:    true,
+					Computed:    true,
+				},
+				{
+					Name:        "result",
+					Type:        tftypes.String,
+					Description: "The generated password.",
+					Computed:    true,
+					Sensitive:   true,
+				},
+				{
+					Name:        "scram_verifier",
+					Type:        tftypes.String,
+					Description: "The SCRAM-SHA-256 verifier of the generated password.",
+					Computed:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+	}
+}
+
+// ephemeralPasswordConfig extracts length and special from config, filling in the defaults.  Unknown values are
+// reported as 0 and false with known set to false.
+func ephemeralPasswordConfig(config *tfprotov5.DynamicValue) (length int, special bool, known bool, err error) {
+	value, err := config.Unmarshal(ephemeralPasswordType)
+	if err != nil {
+		return 0, false, false, err
+	}
+	attributes := map[string]tftypes.Value{}
+	err = value.As(&attributes)
+	if err != nil {
+		return 0, false, false, err
+	}
+	if !attributes["length"].IsKnown() || !attributes["special"].IsKnown() {
+		return 0, false, false, nil
+	}
+	length = ephemeralPasswordDefaultLength
+	if !attributes["length"].IsNull() {
+		var number big.Float
+		err = attributes["length"].As(&number)
+		if err != nil {
+			return 0, false, false, err
+		}
+		if !number.IsInt() {
+			return 0, false, false, fmt.Errorf("length must be a whole number, got %s", number.String())
+		}
+		length64, _ := number.Int64()
+		length = int(length64)
+	}
+	special = true
+	if !attributes["special"].IsNull() {
+		err = attributes["special"].As(&special)
+		if err != nil {
+			return 0, false, false, err
+		}
+	}
+	return length, special, true, nil
+}
+
+func validateEphemeralPasswordLength(length int) error {
+	if (length < ephemeralPasswordMinLength) || (length > ephemeralPasswordMaxLength) {
+		return fmt.Errorf("length must be between %d and %d, got %d", ephemeralPasswordMinLength, ephemeralPasswordMaxLength, length)
+	}
+	return nil
+}
+
+// generatePassword returns a password of length characters drawn uniformly from the alphabet
+func generatePassword(length int, special bool) (string, error) {
+	alphabet := passwordAlphanumeric
+	if special {
+		alphabet += passwordSpecial
+	}
+	password := make([]byte, length)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("Error generating password: %w", err)
+		}
+		password[i] = alphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+func ephemeralPasswordErrorDiagnostic(summary string, err error) []*tfprotov5.Diagnostic {
+	return []*tfprotov5.Diagnostic{
+		{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  summary,
+			Detail:   err.Error(),
+		},
+	}
+}
+
+func validateEphemeralPassword(ctx context.Context, req *tfprotov5.ValidateEphemeralResourceConfigRequest) *tfprotov5.ValidateEphemeralResourceConfigResponse {
+	resp := &tfprotov5.ValidateEphemeralResourceConfigResponse{}
+	length, _, known, err := ephemeralPasswordConfig(req.Config)
+	if err == nil && known {
+		err = validateEphemeralPasswordLength(length)
+	}
+	if err != nil {
+		resp.Diagnostics = ephemeralPasswordErrorDiagnostic("Invalid postgresql_password configuration", err)
+	}
+	return resp
+}
+
+func openEphemeralPassword(ctx context.Context, req *tfprotov5.OpenEphemeralResourceRequest) *tfprotov5.OpenEphemeralResourceResponse {
+	resp := &tfprotov5.OpenEphemeralResourceResponse{}
+	length, special, _, err := ephemeralPasswordConfig(req.Config)
+	if err == nil {
+		err = validateEphemeralPasswordLength(length)
+	}
+	if err != nil {
+		resp.Diagnostics = ephemeralPasswordErrorDiagnostic("Invalid postgresql_password configuration", err)
+		return resp
+	}
+	password, err := generatePassword(length, special)
+	if err != nil {
+		resp.Diagnostics = ephemeralPasswordErrorDiagnostic("Error generating password", err)
+		return resp
+	}
+	verifier, err := client.ScramSHA256Verifier(password)
+	if err != nil {
+		resp.Diagnostics = ephemeralPasswordErrorDiagnostic("Error generating password", err)
+		return resp
+	}
+	result, err := tfprotov5.NewDynamicValue(ephemeralPasswordType, tftypes.NewValue(ephemeralPasswordType, map[string]tftypes.Value{
+		"length":         tftypes.NewValue(tftypes.Number, length),
+		"special":        tftypes.NewValue(tftypes.Bool, special),
+		"result":         tftypes.NewValue(tftypes.String, password),
+		"scram_verifier": tftypes.NewValue(tftypes.String, verifier),
+	}))
+	if err != nil {
+		resp.Diagnostics = ephemeralPasswordErrorDiagnostic("Error generating password", err)
+		return resp
+	}
+	resp.Result = &result
+	return resp
+}
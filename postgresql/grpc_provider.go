@@ -0,0 +1,68 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// grpcProviderServer serves the SDK provider and adds the ephemeral resources, which the SDK does not support
+type grpcProviderServer struct {
+	*schema.GRPCProviderServer
+}
+
+func GRPCProviderServer() tfprotov5.ProviderServer {
+	return &grpcProviderServer{
+		GRPCProviderServer: schema.NewGRPCProviderServer(Provider()),
+	}
+}
+
+func (s *grpcProviderServer) GetMetadata(ctx context.Context, req *tfprotov5.GetMetadataRequest) (*tfprotov5.GetMetadataResponse, error) {
+	resp, err := s.GRPCProviderServer.GetMetadata(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	resp.EphemeralResources = append(resp.EphemeralResources, tfprotov5.EphemeralResourceMetadata{
+		TypeName: ephemeralPasswordTypeName,
+	})
+	return resp, nil
+}
+
+func (s *grpcProviderServer) GetProviderSchema(ctx context.Context, req *tfprotov5.GetProviderSchemaRequest) (*tfprotov5.GetProviderSchemaResponse, error) {
+	resp, err := s.GRPCProviderServer.GetProviderSchema(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	resp.EphemeralResourceSchemas[ephemeralPasswordTypeName] = ephemeralPasswordSchema()
+	return resp, nil
+}
+
+func (s *grpcProviderServer) ValidateEphemeralResourceConfig(ctx context.Context, req *tfprotov5.ValidateEphemeralResourceConfigRequest) (*tfprotov5.ValidateEphemeralResourceConfigResponse, error) {
+	if req.TypeName == ephemeralPasswordTypeName {
+		return validateEphemeralPassword(ctx, req), nil
+	}
+	return s.GRPCProviderServer.ValidateEphemeralResourceConfig(ctx, req)
+}
+
+func (s *grpcProviderServer) OpenEphemeralResource(ctx context.Context, req *tfprotov5.OpenEphemeralResourceRequest) (*tfprotov5.OpenEphemeralResourceResponse, error) {
+	if req.TypeName == ephemeralPasswordTypeName {
+		return openEphemeralPassword(ctx, req), nil
+	}
+	return s.GRPCProviderServer.OpenEphemeralResource(ctx, req)
+}
+
+func (s *grpcProviderServer) RenewEphemeralResource(ctx context.Context, req *tfprotov5.RenewEphemeralResourceRequest) (*tfprotov5.RenewEphemeralResourceResponse, error) {
+	if req.TypeName == ephemeralPasswordTypeName {
+		// Generated passwords do not expire
+		return &tfprotov5.RenewEphemeralResourceResponse{}, nil
+	}
+	return s.GRPCProviderServer.RenewEphemeralResource(ctx, req)
+}
+
+func (s *grpcProviderServer) CloseEphemeralResource(ctx context.Context, req *tfprotov5.CloseEphemeralResourceRequest) (*tfprotov5.CloseEphemeralResourceResponse, error) {
+	if req.TypeName == ephemeralPasswordTypeName {
+		return &tfprotov5.CloseEphemeralResourceResponse{}, nil
+	}
+	return s.GRPCProviderServer.CloseEphemeralResource(ctx, req)
+}
@@ -285,6 +285,16 @@ func permString(perms, grantOptions Privileges) string {
 	return b.String()
 }
 
+// UnquoteRole returns the name of the role of an aclitem, which PostgreSQL
+// only double quotes when needed, like an identifier.  An empty role is PUBLIC.
+func UnquoteRole(role string) string {
+	if len(role) >= 2 && role[0] == '"' && role[len(role)-1] == '"' {
+		return strings.ReplaceAll(role[1:len(role)-1], `""`, `"`)
+	}
+
+	return role
+}
+
 // quoteRole is a small helper function that handles the quoting of a role name,
 // or PUBLIC, if no role is specified.
 func quoteRole(role string) string {
@@ -2,9 +2,11 @@ package postgresql
 
 import (
 	"context"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/scastria/terraform-provider-postgresql/postgresql/client"
 )
 
@@ -24,17 +26,132 @@ func Provider() *schema.Provider {
 			"default_database": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_DEFAULT_DATABASE", "postgres"),
+				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_DEFAULT_DATABASE", nil),
 			},
 			"username": {
 				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_USERNAME", nil),
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_USERNAME", ""),
 			},
 			"password": {
 				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_PASSWORD", nil),
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_PASSWORD", ""),
+			},
+			"password_command": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				ConflictsWith: []string{"password_file", "aws_rds_iam_auth"},
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"password_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"password_command", "aws_rds_iam_auth"},
+				DefaultFunc:   schema.EnvDefaultFunc("POSTGRESQL_PASSWORD_FILE", ""),
+			},
+			"aws_rds_iam_auth": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_AWS_RDS_IAM_AUTH", false),
+			},
+			"aws_rds_iam_region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_AWS_RDS_IAM_REGION", ""),
+			},
+			"aws_rds_iam_profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_AWS_RDS_IAM_PROFILE", ""),
+			},
+			"assume_role": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_ASSUME_ROLE", ""),
+			},
+			"target_session_attrs": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("POSTGRESQL_TARGET_SESSION_ATTRS", ""),
+				ValidateFunc: validation.StringInSlice([]string{"", client.TargetSessionAttrsAny, client.TargetSessionAttrsReadWrite, client.TargetSessionAttrsReadOnly, client.TargetSessionAttrsPrimary, client.TargetSessionAttrsStandby, client.TargetSessionAttrsPreferStandby}, false),
+			},
+			"ssh_tunnel": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"host": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"port": {
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  22,
+						},
+						"user": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"private_key": {
+							Type:      schema.TypeString,
+							Required:  true,
+							Sensitive: true,
+						},
+						"private_key_passphrase": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"known_hosts": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"jump_host": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"host": {
+										Type:     schema.TypeString,
+										Required: true,
+									},
+									"port": {
+										Type:     schema.TypeInt,
+										Optional: true,
+										Default:  22,
+									},
+									"user": {
+										Type:     schema.TypeString,
+										Required: true,
+									},
+									"private_key": {
+										Type:      schema.TypeString,
+										Optional:  true,
+										Sensitive: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"max_retry_attempts": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("POSTGRESQL_MAX_RETRY_ATTEMPTS", 5),
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"retry_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("POSTGRESQL_RETRY_TIMEOUT", 60),
+				ValidateFunc: validation.IntAtLeast(0),
 			},
 			"max_open_connections": {
 				Type:        schema.TypeInt,
@@ -46,13 +163,48 @@ func Provider() *schema.Provider {
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_MAX_IDLE_CONNECTIONS", 2),
 			},
+			"sslmode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("POSTGRESQL_SSLMODE", ""),
+				ValidateFunc: validation.StringInSlice([]string{"", client.SSLModeDisable, client.SSLModeAllow, client.SSLModePrefer, client.SSLModeRequire, client.SSLModeVerifyCA, client.SSLModeVerifyFull}, false),
+			},
+			"sslrootcert": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_SSLROOTCERT", ""),
+			},
+			"sslcert": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"sslkey"},
+				DefaultFunc:  schema.EnvDefaultFunc("POSTGRESQL_SSLCERT", ""),
+			},
+			"sslkey": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"sslcert"},
+				DefaultFunc:  schema.EnvDefaultFunc("POSTGRESQL_SSLKEY", ""),
+			},
+			"sslpassword": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("POSTGRESQL_SSLPASSWORD", ""),
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
+			"postgresql_database":                resourceDatabase(),
+			"postgresql_schema":                  resourceSchema(),
+			"postgresql_extension":               resourceExtension(),
 			"postgresql_role":                    resourceRole(),
 			"postgresql_role_member":             resourceRoleMember(),
+			"postgresql_role_config":             resourceRoleConfig(),
 			"postgresql_role_default_role":       resourceRoleDefaultRole(),
 			"postgresql_role_permission":         resourceRolePermission(),
 			"postgresql_role_default_permission": resourceRoleDefaultPermission(),
+			"postgresql_grant":                   resourceGrant(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"postgresql_databases": dataSourceDatabases(),
@@ -72,11 +224,59 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}
 	defaultDatabase := d.Get("default_database").(string)
 	username := d.Get("username").(string)
 	password := d.Get("password").(string)
+	passwordCommand := []string{}
+	for _, arg := range d.Get("password_command").([]interface{}) {
+		passwordCommand = append(passwordCommand, arg.(string))
+	}
+	passwordSourceConfig := client.PasswordSourceConfig{
+		Command: passwordCommand,
+		File:    d.Get("password_file").(string),
+	}
 	maxOpenConnections := d.Get("max_open_connections").(int)
 	maxIdleConnections := d.Get("max_idle_connections").(int)
+	targetSessionAttrs := d.Get("target_session_attrs").(string)
+	assumeRole := d.Get("assume_role").(string)
+	retryConfig := client.RetryConfig{
+		MaxAttempts: d.Get("max_retry_attempts").(int),
+		Timeout:     time.Duration(d.Get("retry_timeout").(int)) * time.Second,
+	}
+	var sshTunnelConfig *client.SSHTunnelConfig
+	sshTunnels := d.Get("ssh_tunnel").([]interface{})
+	if (len(sshTunnels) > 0) && (sshTunnels[0] != nil) {
+		sshTunnel := sshTunnels[0].(map[string]interface{})
+		sshTunnelConfig = &client.SSHTunnelConfig{
+			Host:                 sshTunnel["host"].(string),
+			Port:                 sshTunnel["port"].(int),
+			User:                 sshTunnel["user"].(string),
+			PrivateKey:           sshTunnel["private_key"].(string),
+			PrivateKeyPassphrase: sshTunnel["private_key_passphrase"].(string),
+			KnownHosts:           sshTunnel["known_hosts"].(string),
+		}
+		for _, jh := range sshTunnel["jump_host"].([]interface{}) {
+			jumpHost := jh.(map[string]interface{})
+			sshTunnelConfig.JumpHosts = append(sshTunnelConfig.JumpHosts, client.SSHJumpHost{
+				Host:       jumpHost["host"].(string),
+				Port:       jumpHost["port"].(int),
+				User:       jumpHost["user"].(string),
+				PrivateKey: jumpHost["private_key"].(string),
+			})
+		}
+	}
+	sslConfig := client.SSLConfig{
+		Mode:        d.Get("sslmode").(string),
+		RootCert:    d.Get("sslrootcert").(string),
+		Cert:        d.Get("sslcert").(string),
+		Key:         d.Get("sslkey").(string),
+		KeyPassword: d.Get("sslpassword").(string),
+	}
+	awsRDSIAMConfig := client.AWSRDSIAMConfig{
+		Enabled: d.Get("aws_rds_iam_auth").(bool),
+		Region:  d.Get("aws_rds_iam_region").(string),
+		Profile: d.Get("aws_rds_iam_profile").(string),
+	}
 
 	var diags diag.Diagnostics
-	c, err := client.NewClient(host, port, defaultDatabase, username, password, maxOpenConnections, maxIdleConnections)
+	c, err := client.NewClient(host, port, defaultDatabase, username, password, passwordSourceConfig, maxOpenConnections, maxIdleConnections, sslConfig, awsRDSIAMConfig, targetSessionAttrs, sshTunnelConfig, retryConfig, assumeRole)
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
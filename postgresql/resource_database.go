@@ -0,0 +1,512 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/lib/pq"
+	_ "github.com/lib/pq"
+	"github.com/scastria/terraform-provider-postgresql/postgresql/client"
+)
+
+func resourceDatabase() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDatabaseCreate,
+		ReadContext:   resourceDatabaseRead,
+		UpdateContext: resourceDatabaseUpdate,
+		DeleteContext: resourceDatabaseDelete,
+		CustomizeDiff: resourceDatabaseCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"owner": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"template": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				// The template is not recorded by the server, so it is unknown after an import
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return (old == "") && (d.Id() != "")
+				},
+			},
+			"encoding": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+				// The server ignores case and punctuation in encoding names, like utf-8 for UTF8
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return normalizeEncoding(old) == normalizeEncoding(new)
+				},
+			},
+			"lc_collate": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"lc_ctype": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"icu_locale": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"tablespace": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"connection_limit": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  -1,
+			},
+			"allow_connections": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"is_template": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"config": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"terminate_connections": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"terminate_block_connections": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"terminate_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+		},
+	}
+}
+
+// terminateSessions terminates every other session whose column of pg_stat_activity (datname or usename) equals
+// value and waits up to timeout for them to end, connected to database.  The PIDs of the terminated sessions are
+// reported as a warning about subject (like database mydb).
+func terminateSessions(ctx context.Context, c *client.Client, database string, subject string, column string, value string, timeout time.Duration) diag.Diagnostics {
+	var diags diag.Diagnostics
+	query, rows, err := c.Query(ctx, database, fmt.Sprintf("select pid, pg_catalog.pg_terminate_backend(pid) from pg_catalog.pg_stat_activity where %s = $1 and pid <> pg_catalog.pg_backend_pid()", column), value)
+	if err != nil {
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	pids := []int64{}
+	for rows.Next() {
+		var pid int64
+		var terminated bool
+		err = rows.Scan(&pid, &terminated)
+		if err != nil {
+			rows.Close()
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+		if terminated {
+			pids = append(pids, pid)
+		}
+	}
+	// Release the connection before polling, the pool may only have one
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	if len(pids) == 0 {
+		return diags
+	}
+	diags = append(diags, diag.Diagnostic{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("Terminated %d sessions of %s", len(pids), subject),
+		Detail:   fmt.Sprintf("PIDs: %s", formatPIDs(pids)),
+	})
+	// pg_terminate_backend only signals the sessions, so wait until they are gone
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := []int64{}
+		query, rows, err := c.Query(ctx, database, "select pid from pg_catalog.pg_stat_activity where pid = any($1)", pq.Array(pids))
+		if err != nil {
+			return append(diags, diag.Errorf("Error executing query: %s, error: %v", query, err)...)
+		}
+		for rows.Next() {
+			var pid int64
+			err = rows.Scan(&pid)
+			if err != nil {
+				rows.Close()
+				return append(diags, diag.Errorf("Error executing query: %s, error: %v", query, err)...)
+			}
+			remaining = append(remaining, pid)
+		}
+		rows.Close()
+		if len(remaining) == 0 {
+			return diags
+		}
+		if time.Now().After(deadline) {
+			return append(diags, diag.Errorf("Sessions of %s did not end within %s after being terminated, PIDs: %s", subject, timeout, formatPIDs(remaining))...)
+		}
+		select {
+		case <-ctx.Done():
+			return append(diags, diag.FromErr(ctx.Err())...)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func formatPIDs(pids []int64) string {
+	formatted := make([]string, 0, len(pids))
+	for _, pid := range pids {
+		formatted = append(formatted, strconv.FormatInt(pid, 10))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func normalizeEncoding(encoding string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", "_", "").Replace(encoding))
+}
+
+// listConfigParameters are the parameters that can be set per database or role and take a list of values, in
+// lower case
+var listConfigParameters = map[string]bool{
+	"datestyle":                 true,
+	"local_preload_libraries":   true,
+	"search_path":               true,
+	"session_preload_libraries": true,
+	"temp_tablespaces":          true,
+}
+
+// quoteConfigValue quotes value of parameter key for ALTER ... SET.  Values of list parameters like search_path are
+// written like in postgresql.conf ("$user", public), so every comma separated element is quoted on its own and
+// double quoted elements stay identifiers.  Values of other parameters are a single literal, even with commas.
+func quoteConfigValue(key string, value string) string {
+	if !listConfigParameters[strings.ToLower(key)] {
+		return pq.QuoteLiteral(value)
+	}
+	elements := splitConfigList(value)
+	quoted := make([]string, 0, len(elements))
+	for _, element := range elements {
+		if (len(element) >= 2) && strings.HasPrefix(element, `"`) && strings.HasSuffix(element, `"`) {
+			inner := element[1 : len(element)-1]
+			if !strings.Contains(strings.ReplaceAll(inner, `""`, ""), `"`) {
+				quoted = append(quoted, pq.QuoteIdentifier(strings.ReplaceAll(inner, `""`, `"`)))
+				continue
+			}
+		}
+		quoted = append(quoted, pq.QuoteLiteral(element))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// splitConfigList splits the value of a list parameter on the commas outside of double quotes.  Elements of a list
+// are trimmed, a single value is kept as is.
+func splitConfigList(value string) []string {
+	elements := []string{}
+	inQuotes := false
+	start := 0
+	for i, r := range value {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if (r == ',') && !inQuotes {
+			elements = append(elements, value[start:i])
+			start = i + 1
+		}
+	}
+	elements = append(elements, value[start:])
+	if len(elements) > 1 {
+		for i, element := range elements {
+			elements[i] = strings.TrimSpace(element)
+		}
+	}
+	return elements
+}
+
+// parseConfig turns a setconfig or rolconfig array of key=value entries into a map
+func parseConfig(settings []string) map[string]string {
+	config := map[string]string{}
+	for _, setting := range settings {
+		key, value, _ := strings.Cut(setting, "=")
+		config[key] = value
+	}
+	return config
+}
+
+// matchConfigKeys spells the keys of config like the keys of managed.  Parameter names are case insensitive and
+// stored with the canonical spelling of the server like TimeZone, so keys are lower cased unless a key of managed
+// matches them case insensitively.  Likewise the server separates the elements of list parameters by ", ", so the
+// managed value is kept when it only differs in the spacing around the commas.
+func matchConfigKeys(config map[string]string, managed map[string]interface{}) map[string]string {
+	spellings := map[string]string{}
+	for key := range managed {
+		spellings[strings.ToLower(key)] = key
+	}
+	result := map[string]string{}
+	for key, value := range config {
+		key = strings.ToLower(key)
+		if spelling, ok := spellings[key]; ok {
+			managedValue := managed[spelling].(string)
+			if listConfigParameters[key] && slices.Equal(splitConfigList(value), splitConfigList(managedValue)) {
+				value = managedValue
+			}
+			key = spelling
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// updateConfig sets every parameter of newConfig on target (like "database mydb") and resets the parameters that
+// are only in oldConfig
+func updateConfig(ctx context.Context, c *client.Client, resourceLockName string, target string, oldConfig map[string]interface{}, newConfig map[string]interface{}) (string, error) {
+	for key := range oldConfig {
+		_, exists := newConfig[key]
+		if exists {
+			continue
+		}
+		query, _, err := c.Exec(ctx, "", resourceLockName, fmt.Sprintf("alter %s reset %s", target, pq.QuoteIdentifier(key)))
+		if err != nil {
+			return query, err
+		}
+	}
+	for key, value := range newConfig {
+		oldValue, exists := oldConfig[key]
+		if exists && (oldValue == value) {
+			continue
+		}
+		query, _, err := c.Exec(ctx, "", resourceLockName, fmt.Sprintf("alter %s set %s = %s", target, pq.QuoteIdentifier(key), quoteConfigValue(key, value.(string))))
+		if err != nil {
+			return query, err
+		}
+	}
+	return "", nil
+}
+
+func resourceDatabaseCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	c := m.(*client.Client)
+	if !d.HasChange("icu_locale") || (d.Get("icu_locale").(string) == "") {
+		return nil
+	}
+	return c.RequireServerVersion("", client.Version15, "icu_locale")
+}
+
+func resourceDatabaseCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	name := d.Get("name").(string)
+	options := []string{}
+	if owner := d.Get("owner").(string); owner != "" {
+		options = append(options, fmt.Sprintf("owner %s", pq.QuoteIdentifier(owner)))
+	}
+	if template := d.Get("template").(string); template != "" {
+		options = append(options, fmt.Sprintf("template %s", pq.QuoteIdentifier(template)))
+	}
+	if encoding := d.Get("encoding").(string); encoding != "" {
+		options = append(options, fmt.Sprintf("encoding %s", pq.QuoteLiteral(encoding)))
+	}
+	if lcCollate := d.Get("lc_collate").(string); lcCollate != "" {
+		options = append(options, fmt.Sprintf("lc_collate %s", pq.QuoteLiteral(lcCollate)))
+	}
+	if lcCtype := d.Get("lc_ctype").(string); lcCtype != "" {
+		options = append(options, fmt.Sprintf("lc_ctype %s", pq.QuoteLiteral(lcCtype)))
+	}
+	if icuLocale := d.Get("icu_locale").(string); icuLocale != "" {
+		options = append(options, fmt.Sprintf("locale_provider icu icu_locale %s", pq.QuoteLiteral(icuLocale)))
+	}
+	if tablespace := d.Get("tablespace").(string); tablespace != "" {
+		options = append(options, fmt.Sprintf("tablespace %s", pq.QuoteIdentifier(tablespace)))
+	}
+	options = append(options, fmt.Sprintf("connection limit %d allow_connections %t is_template %t", d.Get("connection_limit").(int), d.Get("allow_connections").(bool), d.Get("is_template").(bool)))
+	// CREATE DATABASE cannot run inside a transaction, so no resource lock
+	query, _, err := c.Exec(ctx, "", "", fmt.Sprintf("create database %s with %s", pq.QuoteIdentifier(name), strings.Join(options, " ")))
+	if err != nil {
+		d.SetId("")
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	d.SetId(name)
+	query, err = updateConfig(ctx, c, "resourceDatabaseCreate", fmt.Sprintf("database %s", pq.QuoteIdentifier(name)), map[string]interface{}{}, d.Get("config").(map[string]interface{}))
+	if err != nil {
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	return diags
+}
+
+func resourceDatabaseRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	name := d.Id()
+	version, err := c.ServerVersion("")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	icuLocaleColumn := "''"
+	if version >= client.Version17 {
+		icuLocaleColumn = "coalesce(case when d.datlocprovider = 'i' then d.datlocale end, '')"
+	} else if version >= client.Version15 {
+		icuLocaleColumn = "coalesce(d.daticulocale, '')"
+	}
+	var owner, encoding, lcCollate, lcCtype, icuLocale, tablespace string
+	var connectionLimit int
+	var allowConnections, isTemplate bool
+	var setconfig pq.StringArray
+	query, row, err := c.QueryRow(ctx, "", fmt.Sprintf("select pg_catalog.pg_get_userbyid(d.datdba), pg_catalog.pg_encoding_to_char(d.encoding), d.datcollate, d.datctype, %s, t.spcname, d.datconnlimit, d.datallowconn, d.datistemplate, s.setconfig from pg_catalog.pg_database d join pg_catalog.pg_tablespace t on t.oid = d.dattablespace left join pg_catalog.pg_db_role_setting s on s.setdatabase = d.oid and s.setrole = 0 where d.datname = $1", icuLocaleColumn), name)
+	if err != nil {
+		d.SetId("")
+		return diag.FromErr(err)
+	}
+	err = row.Scan(&owner, &encoding, &lcCollate, &lcCtype, &icuLocale, &tablespace, &connectionLimit, &allowConnections, &isTemplate, &setconfig)
+	if err != nil {
+		d.SetId("")
+		if errors.Is(err, sql.ErrNoRows) {
+			return diags
+		}
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	d.Set("name", name)
+	d.Set("owner", owner)
+	d.Set("encoding", encoding)
+	d.Set("lc_collate", lcCollate)
+	d.Set("lc_ctype", lcCtype)
+	d.Set("icu_locale", icuLocale)
+	d.Set("tablespace", tablespace)
+	d.Set("connection_limit", connectionLimit)
+	d.Set("allow_connections", allowConnections)
+	d.Set("is_template", isTemplate)
+	d.Set("config", matchConfigKeys(parseConfig(setconfig), d.Get("config").(map[string]interface{})))
+	return diags
+}
+
+func resourceDatabaseUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	if d.HasChange("name") {
+		oldName, newName := d.GetChange("name")
+		// A database cannot be renamed while there are connections to it, including our own pool
+		c.CloseConn(oldName.(string))
+		query, _, err := c.Exec(ctx, "", "resourceDatabaseUpdate", fmt.Sprintf("alter database %s rename to %s", pq.QuoteIdentifier(oldName.(string)), pq.QuoteIdentifier(newName.(string))))
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+		d.SetId(newName.(string))
+	}
+	name := d.Id()
+	if d.HasChange("owner") {
+		query, _, err := c.Exec(ctx, "", "resourceDatabaseUpdate", fmt.Sprintf("alter database %s owner to %s", pq.QuoteIdentifier(name), pq.QuoteIdentifier(d.Get("owner").(string))))
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+	}
+	if d.HasChange("tablespace") {
+		c.CloseConn(name)
+		// SET TABLESPACE cannot run inside a transaction, so no resource lock
+		query, _, err := c.Exec(ctx, "", "", fmt.Sprintf("alter database %s set tablespace %s", pq.QuoteIdentifier(name), pq.QuoteIdentifier(d.Get("tablespace").(string))))
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+	}
+	if d.HasChanges("connection_limit", "allow_connections", "is_template") {
+		query, _, err := c.Exec(ctx, "", "resourceDatabaseUpdate", fmt.Sprintf("alter database %s with connection limit %d allow_connections %t is_template %t", pq.QuoteIdentifier(name), d.Get("connection_limit").(int), d.Get("allow_connections").(bool), d.Get("is_template").(bool)))
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+	}
+	if d.HasChange("config") {
+		oldConfig, newConfig := d.GetChange("config")
+		query, err := updateConfig(ctx, c, "resourceDatabaseUpdate", fmt.Sprintf("database %s", pq.QuoteIdentifier(name)), oldConfig.(map[string]interface{}), newConfig.(map[string]interface{}))
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+	}
+	return diags
+}
+
+func resourceDatabaseDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	name := d.Id()
+	c.CloseConn(name)
+	// The database cannot be dropped while connected to it, even if it is the default database
+	maintenance := c.MaintenanceDatabase(name)
+	blocked := false
+	if d.Get("terminate_connections").(bool) {
+		if d.Get("terminate_block_connections").(bool) {
+			// Keep new sessions from replacing the terminated ones.  Unlike revoking connect from public, this also
+			// keeps out roles with an explicit connect privilege and superusers.
+			var allowConnections bool
+			query, row, err := c.QueryRow(ctx, maintenance, "select datallowconn from pg_catalog.pg_database where datname = $1", name)
+			if err == nil {
+				err = row.Scan(&allowConnections)
+			}
+			if err != nil {
+				return diag.Errorf("Error executing query: %s, error: %v", query, err)
+			}
+			if allowConnections {
+				query, _, err = c.Exec(ctx, maintenance, "resourceDatabaseDelete", fmt.Sprintf("alter database %s with allow_connections false", pq.QuoteIdentifier(name)))
+				if err != nil {
+					return diag.Errorf("Error executing query: %s, error: %v", query, err)
+				}
+				blocked = true
+			}
+		}
+		diags = append(diags, terminateSessions(ctx, c, maintenance, fmt.Sprintf("database %s", name), "datname", name, time.Duration(d.Get("terminate_timeout").(int))*time.Second)...)
+		if diags.HasError() {
+			return append(diags, restoreDatabaseConnections(ctx, c, maintenance, name, blocked)...)
+		}
+	}
+	// DROP DATABASE cannot run inside a transaction, so no resource lock
+	query, _, err := c.Exec(ctx, maintenance, "", fmt.Sprintf("drop database %s", pq.QuoteIdentifier(name)))
+	if err != nil {
+		diags = append(diags, diag.Errorf("Error executing query: %s, error: %v", query, err)...)
+		return append(diags, restoreDatabaseConnections(ctx, c, maintenance, name, blocked)...)
+	}
+	d.SetId("")
+	return diags
+}
+
+// restoreDatabaseConnections allows connections to a database again that were blocked for dropping it, after the
+// drop did not succeed
+func restoreDatabaseConnections(ctx context.Context, c *client.Client, maintenance string, name string, blocked bool) diag.Diagnostics {
+	if !blocked {
+		return nil
+	}
+	query, _, err := c.Exec(ctx, maintenance, "resourceDatabaseDelete", fmt.Sprintf("alter database %s with allow_connections true", pq.QuoteIdentifier(name)))
+	if err != nil {
+		return diag.Errorf("Error allowing connections to database %s again, executing query: %s, error: %v", name, query, err)
+	}
+	return nil
+}
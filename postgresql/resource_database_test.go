@@ -0,0 +1,93 @@
+package postgresql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuoteConfigValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  string
+	}{
+		{"scalar", "statement_timeout", "30s", `'30s'`},
+		{"scalar with comma", "application_name", "a,b", `'a,b'`},
+		{"scalar with quote", "application_name", "it's", `'it''s'`},
+		{"list", "search_path", "a, b", `'a', 'b'`},
+		{"list without spaces", "search_path", "a,b", `'a', 'b'`},
+		{"list with identifiers", "search_path", `"$user", "My,Schema", public`, `"$user", "My,Schema", 'public'`},
+		{"list with doubled quotes", "search_path", `"My""Schema"`, `"My""Schema"`},
+		{"list single value keeps spaces", "search_path", " a ", `' a '`},
+		{"list parameter is case insensitive", "DateStyle", "ISO,MDY", `'ISO', 'MDY'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteConfigValue(tt.key, tt.value); got != tt.want {
+				t.Errorf("quoteConfigValue(%q, %q) = %s, want %s", tt.key, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	got := parseConfig([]string{"search_path=a, b", "TimeZone=UTC", "application_name=x=y"})
+	want := map[string]string{"search_path": "a, b", "TimeZone": "UTC", "application_name": "x=y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseConfig() = %v, want %v", got, want)
+	}
+}
+
+func TestMatchConfigKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]string
+		managed map[string]interface{}
+		want    map[string]string
+	}{
+		{
+			"canonical spelling of managed key",
+			map[string]string{"TimeZone": "UTC"},
+			map[string]interface{}{"timezone": "UTC"},
+			map[string]string{"timezone": "UTC"},
+		},
+		{
+			"managed spelling kept",
+			map[string]string{"TimeZone": "UTC"},
+			map[string]interface{}{"TimeZone": "UTC"},
+			map[string]string{"TimeZone": "UTC"},
+		},
+		{
+			"unmanaged key lower cased",
+			map[string]string{"TimeZone": "UTC"},
+			map[string]interface{}{},
+			map[string]string{"timezone": "UTC"},
+		},
+		{
+			"list spacing",
+			map[string]string{"search_path": "a, b"},
+			map[string]interface{}{"search_path": "a,b"},
+			map[string]string{"search_path": "a,b"},
+		},
+		{
+			"list drift",
+			map[string]string{"search_path": "a, c"},
+			map[string]interface{}{"search_path": "a,b"},
+			map[string]string{"search_path": "a, c"},
+		},
+		{
+			"scalar spacing is drift",
+			map[string]string{"application_name": "a, b"},
+			map[string]interface{}{"application_name": "a,b"},
+			map[string]string{"application_name": "a, b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchConfigKeys(tt.config, tt.managed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchConfigKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/lib/pq"
+	_ "github.com/lib/pq"
+	"github.com/scastria/terraform-provider-postgresql/postgresql/client"
+)
+
+func resourceExtension() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceExtensionCreate,
+		ReadContext:   resourceExtensionRead,
+		UpdateContext: resourceExtensionUpdate,
+		DeleteContext: resourceExtensionDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"database": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"schema": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"version": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"cascade": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"drop_cascade": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+// checkExtensionVersion fails with the list of installable versions if version of extension is not available on
+// the server behind database
+func checkExtensionVersion(ctx context.Context, c *client.Client, database string, extension string, version string) (string, error) {
+	query, rows, err := c.Query(ctx, database, "select version from pg_catalog.pg_available_extension_versions where name = $1", extension)
+	if err != nil {
+		return query, err
+	}
+	defer rows.Close()
+	versions := []string{}
+	for rows.Next() {
+		var available string
+		err = rows.Scan(&available)
+		if err != nil {
+			return query, err
+		}
+		if available == version {
+			return query, nil
+		}
+		versions = append(versions, available)
+	}
+	err = rows.Err()
+	if err != nil {
+		return query, err
+	}
+	if len(versions) == 0 {
+		return query, fmt.Errorf("extension %s is not available on the server", extension)
+	}
+	return query, fmt.Errorf("version %s of extension %s is not available on the server, available versions: %s", version, extension, strings.Join(versions, ", "))
+}
+
+func resourceExtensionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	database := d.Get("database").(string)
+	name := d.Get("name").(string)
+	options := []string{}
+	if schemaName := d.Get("schema").(string); schemaName != "" {
+		options = append(options, fmt.Sprintf("schema %s", pq.QuoteIdentifier(schemaName)))
+	}
+	if version := d.Get("version").(string); version != "" {
+		options = append(options, fmt.Sprintf("version %s", pq.QuoteLiteral(version)))
+	}
+	if d.Get("cascade").(bool) {
+		options = append(options, "cascade")
+	}
+	query, _, err := c.Exec(ctx, database, "resourceExtensionCreate", fmt.Sprintf("create extension %s %s", pq.QuoteIdentifier(name), strings.Join(options, " ")))
+	if err != nil {
+		d.SetId("")
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	d.SetId(fmt.Sprintf("%s:%s", database, name))
+	return diags
+}
+
+func resourceExtensionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	tokens := strings.Split(d.Id(), ":")
+	database := tokens[0]
+	name := tokens[1]
+	var schemaName, version string
+	query, row, err := c.QueryRow(ctx, database, "select n.nspname, e.extversion from pg_catalog.pg_extension e join pg_catalog.pg_namespace n on n.oid = e.extnamespace where e.extname = $1", name)
+	if err != nil {
+		var dneErr *client.DatabaseNotExistError
+		if errors.As(err, &dneErr) {
+			// Database does not exist, so the extension cannot exist
+			d.SetId("")
+			return diags
+		}
+		return diag.FromErr(err)
+	}
+	err = row.Scan(&schemaName, &version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			d.SetId("")
+			return diags
+		}
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	d.Set("database", database)
+	d.Set("name", name)
+	d.Set("schema", schemaName)
+	d.Set("version", version)
+	return diags
+}
+
+func resourceExtensionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	database := d.Get("database").(string)
+	name := d.Get("name").(string)
+	if d.HasChange("version") {
+		version := d.Get("version").(string)
+		query, err := checkExtensionVersion(ctx, c, database, name, version)
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+		query, _, err = c.Exec(ctx, database, "resourceExtensionUpdate", fmt.Sprintf("alter extension %s update to %s", pq.QuoteIdentifier(name), pq.QuoteLiteral(version)))
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+	}
+	if d.HasChange("schema") {
+		query, _, err := c.Exec(ctx, database, "resourceExtensionUpdate", fmt.Sprintf("alter extension %s set schema %s", pq.QuoteIdentifier(name), pq.QuoteIdentifier(d.Get("schema").(string))))
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+	}
+	return diags
+}
+
+func resourceExtensionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	database := d.Get("database").(string)
+	name := d.Get("name").(string)
+	dropOption := "restrict"
+	if d.Get("drop_cascade").(bool) {
+		dropOption = "cascade"
+	}
+	query, _, err := c.Exec(ctx, database, "resourceExtensionDelete", fmt.Sprintf("drop extension %s %s", pq.QuoteIdentifier(name), dropOption))
+	if err != nil {
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	d.SetId("")
+	return diags
+}
@@ -0,0 +1,692 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/lib/pq"
+	_ "github.com/lib/pq"
+	"github.com/scastria/terraform-provider-postgresql/postgresql/client"
+	"github.com/scastria/terraform-provider-postgresql/postgresql/pgacl"
+)
+
+const (
+	PUBLIC = "public"
+)
+
+// grantObjectPrivileges lists the privileges that can be granted on each object type
+var grantObjectPrivileges = map[string][]string{
+	DATABASE:             {CREATE, CONNECT, TEMPORARY},
+	DOMAIN:               {USAGE},
+	FOREIGN_DATA_WRAPPER: {USAGE},
+	FOREIGN_SERVER:       {USAGE},
+	FUNCTION:             {EXECUTE},
+	LANGUAGE:             {USAGE},
+	LARGE_OBJECT:         {SELECT, UPDATE},
+	PARAMETER:            {SET, ALTER_SYSTEM},
+	PROCEDURE:            {EXECUTE},
+	ROUTINE:              {EXECUTE},
+	SCHEMA:               {CREATE, USAGE},
+	SEQUENCE:             {USAGE, SELECT, UPDATE},
+	TABLE:                {SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER, MAINTAIN},
+	TABLESPACE:           {CREATE},
+	TYPE:                 {USAGE},
+}
+
+// grantObjectACLQueries select the ACL of an object as text[], falling back to the default ACL of its owner when
+// no privileges were ever granted, along with the owner.  The objects of the reg* casts must be quoted, the others
+// are plain names.
+var grantObjectACLQueries = map[string]string{
+	DATABASE:             "select coalesce(datacl, acldefault('d', datdba))::text[], pg_catalog.pg_get_userbyid(datdba) from pg_catalog.pg_database where datname = $1",
+	DOMAIN:               "select coalesce(typacl, acldefault('T', typowner))::text[], pg_catalog.pg_get_userbyid(typowner) from pg_catalog.pg_type where oid = pg_catalog.to_regtype($1)",
+	FOREIGN_DATA_WRAPPER: "select coalesce(fdwacl, acldefault('F', fdwowner))::text[], pg_catalog.pg_get_userbyid(fdwowner) from pg_catalog.pg_foreign_data_wrapper where fdwname = $1",
+	FOREIGN_SERVER:       "select coalesce(srvacl, acldefault('S', srvowner))::text[], pg_catalog.pg_get_userbyid(srvowner) from pg_catalog.pg_foreign_server where srvname = $1",
+	FUNCTION:             "select coalesce(proacl, acldefault('f', proowner))::text[], pg_catalog.pg_get_userbyid(proowner) from pg_catalog.pg_proc where oid = pg_catalog.to_regprocedure($1)",
+	LANGUAGE:             "select coalesce(lanacl, acldefault('l', lanowner))::text[], pg_catalog.pg_get_userbyid(lanowner) from pg_catalog.pg_language where lanname = $1",
+	LARGE_OBJECT:         "select coalesce(lomacl, acldefault('L', lomowner))::text[], pg_catalog.pg_get_userbyid(lomowner) from pg_catalog.pg_largeobject_metadata where oid = $1::oid",
+	PARAMETER:            "select paracl::text[], null from pg_catalog.pg_parameter_acl where parname = lower($1)",
+	PROCEDURE:            "select coalesce(proacl, acldefault('f', proowner))::text[], pg_catalog.pg_get_userbyid(proowner) from pg_catalog.pg_proc where oid = pg_catalog.to_regprocedure($1)",
+	ROUTINE:              "select coalesce(proacl, acldefault('f', proowner))::text[], pg_catalog.pg_get_userbyid(proowner) from pg_catalog.pg_proc where oid = pg_catalog.to_regprocedure($1)",
+	SCHEMA:               "select coalesce(nspacl, acldefault('n', nspowner))::text[], pg_catalog.pg_get_userbyid(nspowner) from pg_catalog.pg_namespace where nspname = $1",
+	SEQUENCE:             "select coalesce(relacl, acldefault('s', relowner))::text[], pg_catalog.pg_get_userbyid(relowner) from pg_catalog.pg_class where oid = pg_catalog.to_regclass($1) and relkind = 'S'",
+	TABLE:                "select coalesce(relacl, acldefault('r', relowner))::text[], pg_catalog.pg_get_userbyid(relowner) from pg_catalog.pg_class where oid = pg_catalog.to_regclass($1) and relkind in ('r', 'v', 'm', 'f', 'p')",
+	TABLESPACE:           "select coalesce(spcacl, acldefault('t', spcowner))::text[], pg_catalog.pg_get_userbyid(spcowner) from pg_catalog.pg_tablespace where spcname = $1",
+	TYPE:                 "select coalesce(typacl, acldefault('T', typowner))::text[], pg_catalog.pg_get_userbyid(typowner) from pg_catalog.pg_type where oid = pg_catalog.to_regtype($1)",
+}
+
+// grantColumnACLQuery selects the ACLs of the columns of a table that ever had privileges granted on them
+const grantColumnACLQuery = "select attname, attacl::text[] from pg_catalog.pg_attribute where attrelid = pg_catalog.to_regclass($1) and attnum > 0 and not attisdropped and attacl is not null"
+
+// grantColumnPrivileges lists the privileges that can be granted on the columns of a table
+var grantColumnPrivileges = []string{SELECT, INSERT, UPDATE, REFERENCES}
+
+var privilegeBits = map[string]pgacl.Privileges{
+	SELECT:       pgacl.Select,
+	INSERT:       pgacl.Insert,
+	UPDATE:       pgacl.Update,
+	DELETE:       pgacl.Delete,
+	TRUNCATE:     pgacl.Truncate,
+	REFERENCES:   pgacl.References,
+	TRIGGER:      pgacl.Trigger,
+	CREATE:       pgacl.Create,
+	CONNECT:      pgacl.Connect,
+	TEMPORARY:    pgacl.Temporary,
+	EXECUTE:      pgacl.Execute,
+	USAGE:        pgacl.Usage,
+	SET:          pgacl.Set,
+	ALTER_SYSTEM: pgacl.System,
+	MAINTAIN:     pgacl.Maintain,
+}
+
+func resourceGrant() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceGrantCreate,
+		ReadContext:   resourceGrantRead,
+		UpdateContext: resourceGrantUpdate,
+		DeleteContext: resourceGrantDelete,
+		CustomizeDiff: resourceGrantCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"role": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"database": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"object_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{DATABASE, DOMAIN, FOREIGN_DATA_WRAPPER, FOREIGN_SERVER, FUNCTION, LANGUAGE, LARGE_OBJECT, PARAMETER, PROCEDURE, ROUTINE, SCHEMA, SEQUENCE, TABLE, TABLESPACE, TYPE}, false),
+			},
+			"schema": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"objects": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotEmpty,
+				},
+			},
+			"columns": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotEmpty,
+				},
+			},
+			"privileges": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice([]string{SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER, CREATE, CONNECT, TEMPORARY, EXECUTE, USAGE, SET, ALTER_SYSTEM, MAINTAIN}, false),
+				},
+			},
+			"with_grant_option": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"granted_by": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"revoke_cascade": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+func resourceGrantCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	c := m.(*client.Client)
+	objectType := d.Get("object_type").(string)
+	if objectType == "" {
+		return nil
+	}
+	switch objectType {
+	case DOMAIN, FUNCTION, PROCEDURE, ROUTINE, SEQUENCE, TABLE, TYPE:
+	default:
+		if d.Get("schema").(string) != "" {
+			return fmt.Errorf("schema cannot be specified for object_type %s", objectType)
+		}
+	}
+	if d.Get("with_grant_option").(bool) && (d.Get("role").(string) == PUBLIC) {
+		return fmt.Errorf("with_grant_option cannot be specified for role %s", PUBLIC)
+	}
+	if d.Get("granted_by").(string) != "" {
+		err := c.RequireServerVersion("", client.Version14, "granted_by")
+		if err != nil {
+			return err
+		}
+	}
+	privileges := d.Get("privileges").(*schema.Set)
+	if d.Get("columns").(*schema.Set).Len() > 0 {
+		if objectType != TABLE {
+			return fmt.Errorf("columns can only be specified for object_type %s", TABLE)
+		}
+		for _, privilege := range privileges.List() {
+			if !slices.Contains(grantColumnPrivileges, privilege.(string)) {
+				return fmt.Errorf("Privilege %s cannot be granted on columns, allowed values: %s", privilege, strings.Join(grantColumnPrivileges, ", "))
+			}
+		}
+	} else {
+		for _, privilege := range privileges.List() {
+			if !slices.Contains(grantObjectPrivileges[objectType], privilege.(string)) {
+				return fmt.Errorf("Privilege %s cannot be granted on object_type %s, allowed values: %s", privilege, objectType, strings.Join(grantObjectPrivileges[objectType], ", "))
+			}
+		}
+	}
+	// Changing the column list is done in place, but switching between privileges on tables and on their columns
+	// replaces the grant
+	if (d.Id() != "") && d.HasChange("columns") {
+		o, n := d.GetChange("columns")
+		if (o.(*schema.Set).Len() == 0) != (n.(*schema.Set).Len() == 0) {
+			err := d.ForceNew("columns")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if objectType == PARAMETER {
+		return c.RequireServerVersion("", client.Version15, "Privileges on parameters")
+	}
+	if privileges.Contains(MAINTAIN) {
+		return c.RequireServerVersion("", client.Version17, "The maintain privilege")
+	}
+	return nil
+}
+
+// quoteGrantee quotes role so that it can be embedded into a grant or revoke statement, where public is a keyword
+func quoteGrantee(role string) string {
+	if role == PUBLIC {
+		return PUBLIC
+	}
+	return pq.QuoteIdentifier(role)
+}
+
+// quoteGrantObject quotes object so that it can be embedded into a grant or revoke statement on objectType,
+// qualifying it by schema if specified
+func quoteGrantObject(objectType string, schemaName string, object string) (string, error) {
+	if schemaName == "" {
+		return quoteTarget(objectType, object)
+	}
+	switch objectType {
+	case FUNCTION, PROCEDURE, ROUTINE:
+		return pq.QuoteIdentifier(schemaName) + "." + quoteRoutineSignature(object), nil
+	default:
+		return pq.QuoteIdentifier(schemaName) + "." + pq.QuoteIdentifier(object), nil
+	}
+}
+
+// grantObjectKey returns the value used to look up the ACL of object in grantObjectACLQueries
+func grantObjectKey(objectType string, schemaName string, object string) (string, error) {
+	switch objectType {
+	case DOMAIN, TYPE:
+		// to_regtype parses the name like a type in SQL, so builtin names like integer must not be quoted
+		if schemaName == "" {
+			return object, nil
+		}
+		return quoteGrantObject(objectType, schemaName, object)
+	case FUNCTION, PROCEDURE, ROUTINE, SEQUENCE, TABLE, LARGE_OBJECT:
+		return quoteGrantObject(objectType, schemaName, object)
+	default:
+		return object, nil
+	}
+}
+
+// splitGrantObjects splits the objects part of an id on the commas that are not part of a routine signature
+func splitGrantObjects(objects string) []string {
+	var result []string
+	depth := 0
+	start := 0
+	for i, r := range objects {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, objects[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(result, objects[start:])
+}
+
+func grantID(d *schema.ResourceData) string {
+	objects := toStrings(d.Get("objects").([]interface{}))
+	id := fmt.Sprintf("%s:%s:%s:%s:%s", d.Get("role").(string), d.Get("database").(string), d.Get("object_type").(string), d.Get("schema").(string), strings.Join(objects, ","))
+	columns := d.Get("columns").(*schema.Set)
+	if columns.Len() > 0 {
+		id += ":" + strings.Join(sortedStrings(columns), ",")
+	}
+	return id
+}
+
+// sortedStrings returns the strings of set in a stable order
+func sortedStrings(set *schema.Set) []string {
+	var result []string
+	for _, value := range set.List() {
+		result = append(result, value.(string))
+	}
+	sort.Strings(result)
+	return result
+}
+
+// execGrant grants or revokes privileges on objects, or on the columns of objects if specified, doing nothing if
+// either privileges or objects are empty.  With grantOptionFor, only the grant option of the privileges is revoked.
+func execGrant(ctx context.Context, c *client.Client, d *schema.ResourceData, lockName string, grant bool, grantOptionFor bool, privileges []string, objects []string, columns []string) error {
+	if (len(privileges) == 0) || (len(objects) == 0) {
+		return nil
+	}
+	objectType := d.Get("object_type").(string)
+	schemaName := d.Get("schema").(string)
+	if len(columns) == 0 {
+		var err error
+		objects, err = unownedGrantObjects(ctx, c, d, objects)
+		if err != nil {
+			return err
+		}
+		if len(objects) == 0 {
+			return nil
+		}
+	}
+	var quotedObjects []string
+	for _, object := range objects {
+		quotedObject, err := quoteGrantObject(objectType, schemaName, object)
+		if err != nil {
+			return err
+		}
+		quotedObjects = append(quotedObjects, quotedObject)
+	}
+	if len(columns) > 0 {
+		var quotedColumns []string
+		for _, column := range columns {
+			quotedColumns = append(quotedColumns, pq.QuoteIdentifier(column))
+		}
+		columnList := strings.Join(quotedColumns, ", ")
+		var columnPrivileges []string
+		for _, privilege := range privileges {
+			columnPrivileges = append(columnPrivileges, fmt.Sprintf("%s (%s)", privilege, columnList))
+		}
+		privileges = columnPrivileges
+	}
+	privilegeList := strings.Join(privileges, ", ")
+	grantee := quoteGrantee(d.Get("role").(string))
+	grantedBy := grantedByClause(d.Get("granted_by").(string))
+	var query string
+	if grant {
+		query = fmt.Sprintf("grant %s on %s %s to %s%s%s", privilegeList, objectType, strings.Join(quotedObjects, ", "), grantee, withGrantOptionClause(d.Get("with_grant_option").(bool)), grantedBy)
+	} else {
+		if grantOptionFor {
+			privilegeList = "grant option for " + privilegeList
+		}
+		query = fmt.Sprintf("revoke %s on %s %s from %s%s%s", privilegeList, objectType, strings.Join(quotedObjects, ", "), grantee, grantedBy, revokeCascadeClause(d.Get("revoke_cascade").(bool)))
+	}
+	query, _, err := c.Exec(ctx, d.Get("database").(string), lockName, query)
+	if err != nil {
+		return fmt.Errorf("Error executing query: %s, error: %w", query, err)
+	}
+	return nil
+}
+
+// unownedGrantObjects leaves out the objects owned by role, whose privileges are implicit and must not be revoked
+func unownedGrantObjects(ctx context.Context, c *client.Client, d *schema.ResourceData, objects []string) ([]string, error) {
+	role := d.Get("role").(string)
+	if role == PUBLIC {
+		return objects, nil
+	}
+	objectType := d.Get("object_type").(string)
+	var result []string
+	for _, object := range objects {
+		key, err := grantObjectKey(objectType, d.Get("schema").(string), object)
+		if err != nil {
+			return nil, err
+		}
+		granted, err := grantedPrivileges(ctx, c, d.Get("database").(string), role, "", objectType, key)
+		if err != nil {
+			return nil, err
+		}
+		if !granted.owned {
+			result = append(result, object)
+		}
+	}
+	return result, nil
+}
+
+func resourceGrantCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.Client)
+	err := execGrant(ctx, c, d, "resourceGrantCreate", true, false, sortedStrings(d.Get("privileges").(*schema.Set)), toStrings(d.Get("objects").([]interface{})), sortedStrings(d.Get("columns").(*schema.Set)))
+	if err != nil {
+		d.SetId("")
+		return diag.FromErr(err)
+	}
+	d.SetId(grantID(d))
+	return resourceGrantRead(ctx, d, m)
+}
+
+func toStrings(values []interface{}) []string {
+	var result []string
+	for _, value := range values {
+		result = append(result, value.(string))
+	}
+	return result
+}
+
+// diffStrings splits old and new into the values kept, removed from old and added by new
+func diffStrings(old []string, new []string) ([]string, []string, []string) {
+	var kept, removed, added []string
+	for _, value := range old {
+		if slices.Contains(new, value) {
+			kept = append(kept, value)
+		} else {
+			removed = append(removed, value)
+		}
+	}
+	for _, value := range new {
+		if !slices.Contains(old, value) {
+			added = append(added, value)
+		}
+	}
+	return kept, removed, added
+}
+
+// grantedACL holds the privileges and grant options of a role according to an ACL
+type grantedACL struct {
+	privileges   pgacl.Privileges
+	grantOptions pgacl.Privileges
+	// owned tells whether role owns the object
+	owned bool
+}
+
+// parseGrantedACL returns what role holds directly according to acls, limited to what was granted by grantedBy if
+// specified
+func parseGrantedACL(acls []string, role string, grantedBy string) (grantedACL, error) {
+	grantee := role
+	if role == PUBLIC {
+		grantee = ""
+	}
+	var granted grantedACL
+	for _, item := range acls {
+		acl, err := pgacl.Parse(item)
+		if err != nil {
+			return grantedACL{}, fmt.Errorf("Error parsing ACL: %s, error: %w", item, err)
+		}
+		if pgacl.UnquoteRole(acl.Role) != grantee {
+			continue
+		}
+		if (grantedBy != "") && (pgacl.UnquoteRole(acl.GrantedBy) != grantedBy) {
+			continue
+		}
+		granted.privileges |= acl.Privileges
+		granted.grantOptions |= acl.GrantOptions
+	}
+	return granted, nil
+}
+
+// grantedPrivileges returns what role holds directly on an object according to its ACL
+func grantedPrivileges(ctx context.Context, c *client.Client, database string, role string, grantedBy string, objectType string, key string) (grantedACL, error) {
+	var acls pq.StringArray
+	var owner sql.NullString
+	query, row, err := c.QueryRow(ctx, database, grantObjectACLQueries[objectType], key)
+	if err != nil {
+		return grantedACL{}, err
+	}
+	err = row.Scan(&acls, &owner)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The object does not exist, so nothing is granted on it
+			return grantedACL{}, nil
+		}
+		return grantedACL{}, fmt.Errorf("Error executing query: %s, error: %w", query, err)
+	}
+	if owner.Valid && (owner.String == role) {
+		// The privileges of the owner are implicit, even once listed in the ACL, so they are not read as granted
+		return grantedACL{owned: true}, nil
+	}
+	return parseGrantedACL(acls, role, grantedBy)
+}
+
+// grantedColumnPrivileges returns what role holds directly on each column of a table according to their ACLs,
+// leaving out the columns without any ACL
+func grantedColumnPrivileges(ctx context.Context, c *client.Client, database string, role string, grantedBy string, key string) (map[string]grantedACL, error) {
+	query, rows, err := c.Query(ctx, database, grantColumnACLQuery, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := map[string]grantedACL{}
+	for rows.Next() {
+		var column string
+		var acls pq.StringArray
+		err = rows.Scan(&column, &acls)
+		if err != nil {
+			return nil, fmt.Errorf("Error executing query: %s, error: %w", query, err)
+		}
+		granted, err := parseGrantedACL(acls, role, grantedBy)
+		if err != nil {
+			return nil, err
+		}
+		result[column] = granted
+	}
+	return result, rows.Err()
+}
+
+func resourceGrantRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	tokens := strings.SplitN(d.Id(), ":", 6)
+	if len(tokens) < 5 {
+		return diag.Errorf("Invalid id: %s, expected role:database:object_type:schema:objects[:columns]", d.Id())
+	}
+	role := tokens[0]
+	database := tokens[1]
+	objectType := tokens[2]
+	schemaName := tokens[3]
+	objects := splitGrantObjects(tokens[4])
+	var columns []string
+	if len(tokens) == 6 {
+		columns = strings.Split(tokens[5], ",")
+	}
+	if _, ok := grantObjectACLQueries[objectType]; !ok {
+		return diag.Errorf("Invalid object_type: %s", objectType)
+	}
+	validPrivileges := grantObjectPrivileges[objectType]
+	if len(columns) > 0 {
+		validPrivileges = grantColumnPrivileges
+	}
+	grantedBy := d.Get("granted_by").(string)
+	// Collect what is granted on each object, or on each managed column of each object, along with the other columns
+	// that have privileges so that they are revoked
+	var granted []grantedACL
+	var otherColumns []string
+	for _, object := range objects {
+		key, err := grantObjectKey(objectType, schemaName, object)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		objectGranted := grantedACL{}
+		columnsGranted := map[string]grantedACL{}
+		if len(columns) == 0 {
+			objectGranted, err = grantedPrivileges(ctx, c, database, role, grantedBy, objectType, key)
+		} else {
+			columnsGranted, err = grantedColumnPrivileges(ctx, c, database, role, grantedBy, key)
+		}
+		if err != nil {
+			var dneErr *client.DatabaseNotExistError
+			if errors.As(err, &dneErr) {
+				// Database does not exist, so the grant cannot exist
+				d.SetId("")
+				return diags
+			}
+			return diag.FromErr(err)
+		}
+		if len(columns) == 0 {
+			// The privileges of role on objects it owns are left out, so that they are neither granted nor revoked
+			if !objectGranted.owned {
+				granted = append(granted, objectGranted)
+			}
+			continue
+		}
+		for _, column := range columns {
+			granted = append(granted, columnsGranted[column])
+		}
+		for column, columnGranted := range columnsGranted {
+			if (columnGranted.privileges != pgacl.NoPrivs) && !slices.Contains(columns, column) && !slices.Contains(otherColumns, column) {
+				otherColumns = append(otherColumns, column)
+			}
+		}
+	}
+	// everywhere holds the privileges granted on all of them, anywhere those granted on at least one
+	everywhere := privilegeBitsOf(validPrivileges)
+	anywhere := pgacl.NoPrivs
+	optionEverywhere := everywhere
+	optionAnywhere := pgacl.NoPrivs
+	for _, g := range granted {
+		everywhere &= g.privileges
+		anywhere |= g.privileges
+		optionEverywhere &= g.grantOptions
+		optionAnywhere |= g.grantOptions
+	}
+	// A managed privilege missing anywhere is reported as missing so that it is granted again, while any other
+	// privilege found anywhere is reported so that it is revoked
+	managed := d.Get("privileges").(*schema.Set)
+	var privileges []string
+	held := pgacl.NoPrivs
+	for _, privilege := range validPrivileges {
+		bit := privilegeBits[privilege]
+		if managed.Contains(privilege) && (everywhere&bit != 0) {
+			privileges = append(privileges, privilege)
+			held |= bit
+		} else if !managed.Contains(privilege) && (anywhere&bit != 0) {
+			privileges = append(privileges, privilege)
+		}
+	}
+	// Likewise the grant option is reported when expected only if held for all privileges reported above, and when
+	// not expected if found for any privilege
+	withGrantOption := optionAnywhere != pgacl.NoPrivs
+	if d.Get("with_grant_option").(bool) {
+		withGrantOption = optionEverywhere&held == held
+	}
+	d.Set("role", role)
+	if database != "" {
+		d.Set("database", database)
+	}
+	d.Set("object_type", objectType)
+	if schemaName != "" {
+		d.Set("schema", schemaName)
+	}
+	d.Set("objects", objects)
+	if len(columns) > 0 {
+		d.Set("columns", append(columns, otherColumns...))
+	}
+	d.Set("privileges", privileges)
+	d.Set("with_grant_option", withGrantOption)
+	return diags
+}
+
+func privilegeBitsOf(privileges []string) pgacl.Privileges {
+	result := pgacl.NoPrivs
+	for _, privilege := range privileges {
+		result |= privilegeBits[privilege]
+	}
+	return result
+}
+
+func resourceGrantUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.Client)
+	o, n := d.GetChange("privileges")
+	oldPrivileges := o.(*schema.Set)
+	newPrivileges := n.(*schema.Set)
+	o, n = d.GetChange("objects")
+	keptObjects, removedObjects, addedObjects := diffStrings(toStrings(o.([]interface{})), toStrings(n.([]interface{})))
+	o, n = d.GetChange("columns")
+	oldColumns := sortedStrings(o.(*schema.Set))
+	newColumns := sortedStrings(n.(*schema.Set))
+	keptColumns, removedColumns, addedColumns := diffStrings(oldColumns, newColumns)
+	// Objects and columns no longer managed lose all privileges, new ones get all of them and the others only the
+	// changes.  Switching between privileges on tables and on their columns replaces the grant, so either all or none
+	// of the column lists are empty.
+	err := execGrant(ctx, c, d, "resourceGrantUpdate", false, false, sortedStrings(oldPrivileges), removedObjects, oldColumns)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if len(removedColumns) > 0 {
+		err = execGrant(ctx, c, d, "resourceGrantUpdate", false, false, sortedStrings(oldPrivileges), keptObjects, removedColumns)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if (len(newColumns) == 0) || (len(keptColumns) > 0) {
+		err = execGrant(ctx, c, d, "resourceGrantUpdate", false, false, sortedStrings(oldPrivileges.Difference(newPrivileges)), keptObjects, keptColumns)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		grants := newPrivileges.Difference(oldPrivileges)
+		if d.HasChange("with_grant_option") {
+			if d.Get("with_grant_option").(bool) {
+				// Granting again adds the grant option
+				grants = newPrivileges
+			} else {
+				err = execGrant(ctx, c, d, "resourceGrantUpdate", false, true, sortedStrings(newPrivileges.Intersection(oldPrivileges)), keptObjects, keptColumns)
+				if err != nil {
+					return diag.FromErr(err)
+				}
+			}
+		}
+		err = execGrant(ctx, c, d, "resourceGrantUpdate", true, false, sortedStrings(grants), keptObjects, keptColumns)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if len(addedColumns) > 0 {
+		err = execGrant(ctx, c, d, "resourceGrantUpdate", true, false, sortedStrings(newPrivileges), keptObjects, addedColumns)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	err = execGrant(ctx, c, d, "resourceGrantUpdate", true, false, sortedStrings(newPrivileges), addedObjects, newColumns)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(grantID(d))
+	return resourceGrantRead(ctx, d, m)
+}
+
+func resourceGrantDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	err := execGrant(ctx, c, d, "resourceGrantDelete", false, false, sortedStrings(d.Get("privileges").(*schema.Set)), toStrings(d.Get("objects").([]interface{})), sortedStrings(d.Get("columns").(*schema.Set)))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("")
+	return diags
+}
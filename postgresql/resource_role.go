@@ -5,9 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/lib/pq"
 	_ "github.com/lib/pq"
 	"github.com/scastria/terraform-provider-postgresql/postgresql/client"
 )
@@ -18,6 +22,7 @@ func resourceRole() *schema.Resource {
 		ReadContext:   resourceRoleRead,
 		UpdateContext: resourceRoleUpdate,
 		DeleteContext: resourceRoleDelete,
+		CustomizeDiff: resourceRoleCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -37,47 +42,410 @@ func resourceRole() *schema.Resource {
 				Default:  true,
 			},
 			"password": {
-				Type:      schema.TypeString,
-				Optional:  true,
-				Sensitive: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"password_wo", "rotation_days"},
+			},
+			"password_wo": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				WriteOnly:     true,
+				ConflictsWith: []string{"password"},
+			},
+			"password_wo_version": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				RequiredWith: []string{"password_wo"},
+			},
+			"superuser": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"createdb": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"createrole": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"replication": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"bypassrls": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"connection_limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(-1),
+			},
+			"valid_until": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     validateValidUntil,
+				DiffSuppressFunc: suppressValidUntilDiff,
+				ConflictsWith:    []string{"rotation_days"},
+			},
+			"rotation_days": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"rotation_window_days": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      7,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"rotation_password_command": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"rotation_store_password": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"rotated_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"rotated_password": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The random password set by the last rotation if no rotation_password_command is given, which requires opting in with rotation_store_password since it is persisted in state in plain text.",
+			},
+			"terminate_connections": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"terminate_block_connections": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"terminate_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"on_destroy": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"reassign_owned_to": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"drop_owned": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+					},
+				},
 			},
 		},
 	}
 }
 
+// releaseOwned reassigns and/or drops everything role owns or is granted in every database postgresql_databases
+// lists by default that allows connections, so that the role can be dropped
+func releaseOwned(ctx context.Context, c *client.Client, d *schema.ResourceData, role string) (string, error) {
+	onDestroy := d.Get("on_destroy").([]interface{})
+	if (len(onDestroy) == 0) || (onDestroy[0] == nil) {
+		return "", nil
+	}
+	options := onDestroy[0].(map[string]interface{})
+	reassignOwnedTo := options["reassign_owned_to"].(string)
+	dropOwned := options["drop_owned"].(bool)
+	if (reassignOwnedTo == "") && !dropOwned {
+		return "", nil
+	}
+	query, databases, err := listDatabases(ctx, c, false, true)
+	if err != nil {
+		return query, err
+	}
+	for _, database := range databases {
+		if reassignOwnedTo != "" {
+			query, _, err = c.Exec(ctx, database, "resourceRoleDelete", fmt.Sprintf("reassign owned by %s to %s", pq.QuoteIdentifier(role), pq.QuoteIdentifier(reassignOwnedTo)))
+			if err != nil {
+				return query, fmt.Errorf("in database %s: %w", database, err)
+			}
+		}
+		// Also revokes all privileges granted to the role
+		if dropOwned {
+			query, _, err = c.Exec(ctx, database, "resourceRoleDelete", fmt.Sprintf("drop owned by %s", pq.QuoteIdentifier(role)))
+			if err != nil {
+				return query, fmt.Errorf("in database %s: %w", database, err)
+			}
+		}
+	}
+	return "", nil
+}
+
+// rotationPasswordSource provides the new password when a role in rotation mode is rotated
+type rotationPasswordSource interface {
+	NewPassword(ctx context.Context, role string) (string, error)
+}
+
+// commandRotationPasswordSource runs rotation_password_command with the role in POSTGRESQL_ROLE, so that the
+// command can generate the password and store it wherever the users of the role read it from
+type commandRotationPasswordSource struct {
+	command []string
+}
+
+func (s *commandRotationPasswordSource) NewPassword(ctx context.Context, role string) (string, error) {
+	return client.RunPasswordCommand(ctx, "rotation_password_command", s.command, "POSTGRESQL_ROLE="+role)
+}
+
+// randomRotationPasswordSource generates a random password, which is kept in rotated_password when
+// rotation_store_password is set
+type randomRotationPasswordSource struct{}
+
+func (s *randomRotationPasswordSource) NewPassword(ctx context.Context, role string) (string, error) {
+	return generatePassword(ephemeralPasswordDefaultLength, true)
+}
+
+func newRotationPasswordSource(d *schema.ResourceData) (rotationPasswordSource, error) {
+	command := []string{}
+	for _, arg := range d.Get("rotation_password_command").([]interface{}) {
+		command = append(command, arg.(string))
+	}
+	if len(command) == 0 {
+		if !d.Get("rotation_store_password").(bool) {
+			return nil, errors.New("rotation_days requires rotation_password_command, unless rotation_store_password is set to keep a random password in state")
+		}
+		return &randomRotationPasswordSource{}, nil
+	}
+	if command[0] == "" {
+		return nil, errors.New("rotation_password_command must start with an executable")
+	}
+	return &commandRotationPasswordSource{command: command}, nil
+}
+
+// rotationDue tells whether a password valid until validUntil expires within windowDays
+func rotationDue(validUntil string, windowDays int) bool {
+	if (validUntil == "") || (validUntil == "infinity") {
+		return true
+	}
+	t, err := parseValidUntil(validUntil)
+	if err != nil {
+		return true
+	}
+	return time.Until(t) <= time.Duration(windowDays)*24*time.Hour
+}
+
+// resourceRoleCustomizeDiff plans a rotation of roles in rotation mode whose password is about to expire according
+// to rolvaliduntil, or whose rotation_days changed
+func resourceRoleCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	rotationDays := d.Get("rotation_days").(int)
+	if rotationDays == 0 {
+		return nil
+	}
+	// The random password would only be known through the state, which must be opted in to
+	if d.NewValueKnown("rotation_password_command") && (len(d.Get("rotation_password_command").([]interface{})) == 0) && !d.Get("rotation_store_password").(bool) {
+		return errors.New("rotation_days requires rotation_password_command, unless rotation_store_password is set to keep a random password in state")
+	}
+	// Otherwise the password is due again right after every rotation
+	if windowDays := d.Get("rotation_window_days").(int); windowDays >= rotationDays {
+		return fmt.Errorf("rotation_window_days (%d) must be less than rotation_days (%d)", windowDays, rotationDays)
+	}
+	if d.Id() == "" {
+		return nil
+	}
+	if !d.HasChange("rotation_days") && !rotationDue(d.Get("valid_until").(string), d.Get("rotation_window_days").(int)) {
+		return nil
+	}
+	for _, key := range []string{"valid_until", "rotated_at", "rotated_password"} {
+		err := d.SetNewComputed(key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotateRole restarts the validity period of a role in rotation mode.  Unless keepPassword is set, the password is
+// replaced with a new one from the rotation password source.
+func rotateRole(ctx context.Context, c *client.Client, d *schema.ResourceData, resourceLockName string, keepPassword bool) (string, error) {
+	name := d.Id()
+	now := time.Now().UTC()
+	validUntil := now.AddDate(0, 0, d.Get("rotation_days").(int)).Format(time.RFC3339)
+	options := []string{fmt.Sprintf("valid until %s", pq.QuoteLiteral(validUntil))}
+	rotatedPassword := d.Get("rotated_password").(string)
+	if !keepPassword {
+		source, err := newRotationPasswordSource(d)
+		if err != nil {
+			return "", err
+		}
+		password, err := source.NewPassword(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		if password == "" {
+			return "", fmt.Errorf("rotation password source returned an empty password for role %s", name)
+		}
+		verifier, err := client.ScramSHA256Verifier(password)
+		if err != nil {
+			return "", err
+		}
+		options = append(options, fmt.Sprintf("password %s", pq.QuoteLiteral(verifier)))
+		rotatedPassword = ""
+		if _, ok := source.(*randomRotationPasswordSource); ok {
+			rotatedPassword = password
+		}
+	}
+	query, _, err := c.Exec(ctx, "", resourceLockName, fmt.Sprintf("alter role %s with %s", pq.QuoteIdentifier(name), strings.Join(options, " ")))
+	if err != nil {
+		return query, err
+	}
+	d.Set("valid_until", validUntil)
+	d.Set("rotated_at", now.Format(time.RFC3339))
+	d.Set("rotated_password", rotatedPassword)
+	return "", nil
+}
+
+// roleAttributes are the boolean attributes of a role which are disabled with a no prefix, like nosuperuser.  They
+// are Computed, so that roles whose attributes are granted by postgresql_role_permission at level global do not
+// drift.
+var roleAttributes = []string{"superuser", "createdb", "createrole", "replication", "bypassrls"}
+
+// validUntilLayouts are the accepted formats of valid_until besides infinity
+var validUntilLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05", "2006-01-02"}
+
+func parseValidUntil(validUntil string) (time.Time, error) {
+	for _, layout := range validUntilLayouts {
+		t, err := time.Parse(layout, validUntil)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is neither infinity nor a timestamp like 2006-01-02T15:04:05Z", validUntil)
+}
+
+func validateValidUntil(i interface{}, k string) ([]string, []error) {
+	validUntil := i.(string)
+	if validUntil == "infinity" {
+		return nil, nil
+	}
+	_, err := parseValidUntil(validUntil)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: %w", k, err)}
+	}
+	return nil, nil
+}
+
+// suppressValidUntilDiff compares timestamps instead of strings, because the server reports them in UTC
+func suppressValidUntilDiff(k, old, new string, d *schema.ResourceData) bool {
+	if (old == "infinity") || (new == "infinity") {
+		return old == new
+	}
+	oldTime, err := parseValidUntil(old)
+	if err != nil {
+		return false
+	}
+	newTime, err := parseValidUntil(new)
+	if err != nil {
+		return false
+	}
+	return oldTime.Equal(newTime)
+}
+
+// roleOptions returns the options of create or alter role.  login and inherit are always included, the other
+// attributes only if they are configured (create) or changed (update).
+func roleOptions(d *schema.ResourceData, isCreate bool) ([]string, error) {
+	included := func(key string) bool {
+		if isCreate {
+			return !d.GetRawConfig().GetAttr(key).IsNull()
+		}
+		return d.HasChange(key)
+	}
+	options := []string{}
+	for _, attribute := range append([]string{"login", "inherit"}, roleAttributes...) {
+		if (attribute != "login") && (attribute != "inherit") && !included(attribute) {
+			continue
+		}
+		if d.Get(attribute).(bool) {
+			options = append(options, attribute)
+		} else {
+			options = append(options, "no"+attribute)
+		}
+	}
+	if included("connection_limit") {
+		options = append(options, fmt.Sprintf("connection limit %d", d.Get("connection_limit").(int)))
+	}
+	// In rotation mode valid_until is set by rotateRole
+	if included("valid_until") && (d.Get("rotation_days").(int) == 0) {
+		validUntil := d.Get("valid_until").(string)
+		// Timestamps without a zone are UTC, not in the time zone of the session
+		if t, err := parseValidUntil(validUntil); err == nil {
+			validUntil = t.UTC().Format(time.RFC3339)
+		}
+		options = append(options, fmt.Sprintf("valid until %s", pq.QuoteLiteral(validUntil)))
+	}
+	password := d.Get("password").(string)
+	// A write-only password is never in state, so it is only sent on create and when its version changes
+	if (isCreate || d.HasChange("password_wo_version")) && (password == "") {
+		passwordWO := d.GetRawConfig().GetAttr("password_wo")
+		if passwordWO.IsKnown() && !passwordWO.IsNull() {
+			password = passwordWO.AsString()
+		}
+	}
+	if password != "" {
+		// Only the verifier is sent, so the plaintext password never reaches the server or its logs
+		verifier, err := client.ScramSHA256Verifier(password)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, fmt.Sprintf("password %s", pq.QuoteLiteral(verifier)))
+	}
+	return options, nil
+}
+
 func resourceRoleCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*client.Client)
 	name := d.Get("name").(string)
-	login := d.Get("login").(bool)
-	inherit := d.Get("inherit").(bool)
-	password, ok := d.GetOk("password")
-	passwordStr := ""
-	if ok {
-		passwordStr = password.(string)
-	}
-	loginOption := ""
-	if login {
-		loginOption = "login"
-	} else {
-		loginOption = "nologin"
-	}
-	inheritOption := ""
-	if inherit {
-		inheritOption = "inherit"
-	} else {
-		inheritOption = "noinherit"
-	}
-	passwordOption := ""
-	if passwordStr != "" {
-		passwordOption = fmt.Sprintf("password '%s'", passwordStr)
-	}
-	query, _, err := c.Exec(ctx, "", "resourceRoleCreate", "create role \"%s\" with %s %s %s", name, loginOption, inheritOption, passwordOption)
+	options, err := roleOptions(d, true)
+	if err != nil {
+		d.SetId("")
+		return diag.FromErr(err)
+	}
+	query, _, err := c.Exec(ctx, "", "resourceRoleCreate", fmt.Sprintf("create role %s with %s", pq.QuoteIdentifier(name), strings.Join(options, " ")))
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
 	}
 	d.SetId(name)
+	if d.Get("rotation_days").(int) > 0 {
+		// A configured write-only password was already set by create role
+		passwordWO := d.GetRawConfig().GetAttr("password_wo")
+		query, err = rotateRole(ctx, c, d, "resourceRoleCreate", passwordWO.IsKnown() && !passwordWO.IsNull())
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+	}
 	return diags
 }
 
@@ -85,13 +453,15 @@ func resourceRoleRead(ctx context.Context, d *schema.ResourceData, m interface{}
 	var diags diag.Diagnostics
 	c := m.(*client.Client)
 	name := d.Id()
-	var login, inherit bool
-	query, row, err := c.QueryRow(ctx, "", "select rolcanlogin, rolinherit from pg_catalog.pg_roles where rolname = '%s'", name)
+	var login, inherit, superuser, createdb, createrole, replication, bypassrls bool
+	var connectionLimit int
+	var validUntil string
+	query, row, err := c.QueryRow(ctx, "", `select rolcanlogin, rolinherit, rolsuper, rolcreatedb, rolcreaterole, rolreplication, rolbypassrls, rolconnlimit, case when rolvaliduntil is null or rolvaliduntil = 'infinity' then 'infinity' else to_char(rolvaliduntil at time zone 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"') end from pg_catalog.pg_roles where rolname = $1`, name)
 	if err != nil {
 		d.SetId("")
 		return diag.FromErr(err)
 	}
-	err = row.Scan(&login, &inherit)
+	err = row.Scan(&login, &inherit, &superuser, &createdb, &createrole, &replication, &bypassrls, &connectionLimit, &validUntil)
 	if err != nil {
 		d.SetId("")
 		if errors.Is(err, sql.ErrNoRows) {
@@ -102,6 +472,13 @@ func resourceRoleRead(ctx context.Context, d *schema.ResourceData, m interface{}
 	d.Set("name", name)
 	d.Set("login", login)
 	d.Set("inherit", inherit)
+	d.Set("superuser", superuser)
+	d.Set("createdb", createdb)
+	d.Set("createrole", createrole)
+	d.Set("replication", replication)
+	d.Set("bypassrls", bypassrls)
+	d.Set("connection_limit", connectionLimit)
+	d.Set("valid_until", validUntil)
 	return diags
 }
 
@@ -110,40 +487,28 @@ func resourceRoleUpdate(ctx context.Context, d *schema.ResourceData, m interface
 	c := m.(*client.Client)
 	if d.HasChange("name") {
 		oldName, newName := d.GetChange("name")
-		query, _, err := c.Exec(ctx, "", "resourceRoleUpdate", "alter role \"%s\" rename to \"%s\"", oldName.(string), newName.(string))
+		query, _, err := c.Exec(ctx, "", "resourceRoleUpdate", fmt.Sprintf("alter role %s rename to %s", pq.QuoteIdentifier(oldName.(string)), pq.QuoteIdentifier(newName.(string))))
 		if err != nil {
 			return diag.Errorf("Error executing query: %s, error: %v", query, err)
 		}
 		d.SetId(newName.(string))
 	}
 	name := d.Id()
-	login := d.Get("login").(bool)
-	inherit := d.Get("inherit").(bool)
-	password, ok := d.GetOk("password")
-	passwordStr := ""
-	if ok {
-		passwordStr = password.(string)
-	}
-	loginOption := ""
-	if login {
-		loginOption = "login"
-	} else {
-		loginOption = "nologin"
-	}
-	inheritOption := ""
-	if inherit {
-		inheritOption = "inherit"
-	} else {
-		inheritOption = "noinherit"
-	}
-	passwordOption := ""
-	if passwordStr != "" {
-		passwordOption = fmt.Sprintf("password '%s'", passwordStr)
-	}
-	query, _, err := c.Exec(ctx, "", "resourceRoleUpdate", "alter role \"%s\" with %s %s %s", name, loginOption, inheritOption, passwordOption)
+	options, err := roleOptions(d, false)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	query, _, err := c.Exec(ctx, "", "resourceRoleUpdate", fmt.Sprintf("alter role %s with %s", pq.QuoteIdentifier(name), strings.Join(options, " ")))
 	if err != nil {
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
 	}
+	if (d.Get("rotation_days").(int) > 0) && d.HasChanges("rotated_at", "rotation_days") {
+		// A new write-only password version was already set above
+		query, err = rotateRole(ctx, c, d, "resourceRoleUpdate", d.HasChange("password_wo_version"))
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+	}
 	return diags
 }
 
@@ -151,10 +516,54 @@ func resourceRoleDelete(ctx context.Context, d *schema.ResourceData, m interface
 	var diags diag.Diagnostics
 	c := m.(*client.Client)
 	name := d.Id()
-	query, _, err := c.Exec(ctx, "", "resourceRoleDelete", "drop role \"%s\"", name)
+	blocked := false
+	if d.Get("terminate_connections").(bool) {
+		if d.Get("terminate_block_connections").(bool) {
+			// Keep new sessions from replacing the terminated ones
+			var canLogin bool
+			query, row, err := c.QueryRow(ctx, "", "select rolcanlogin from pg_catalog.pg_roles where rolname = $1", name)
+			if err == nil {
+				err = row.Scan(&canLogin)
+			}
+			if err != nil {
+				return diag.Errorf("Error executing query: %s, error: %v", query, err)
+			}
+			if canLogin {
+				query, _, err = c.Exec(ctx, "", "resourceRoleDelete", fmt.Sprintf("alter role %s with nologin", pq.QuoteIdentifier(name)))
+				if err != nil {
+					return diag.Errorf("Error executing query: %s, error: %v", query, err)
+				}
+				blocked = true
+			}
+		}
+		diags = append(diags, terminateSessions(ctx, c, "", fmt.Sprintf("role %s", name), "usename", name, time.Duration(d.Get("terminate_timeout").(int))*time.Second)...)
+		if diags.HasError() {
+			return append(diags, restoreRoleLogin(ctx, c, name, blocked)...)
+		}
+	}
+	query, err := releaseOwned(ctx, c, d, name)
 	if err != nil {
-		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		diags = append(diags, diag.Errorf("Error executing query: %s, error: %v", query, err)...)
+		return append(diags, restoreRoleLogin(ctx, c, name, blocked)...)
+	}
+	query, _, err = c.Exec(ctx, "", "resourceRoleDelete", fmt.Sprintf("drop role %s", pq.QuoteIdentifier(name)))
+	if err != nil {
+		diags = append(diags, diag.Errorf("Error executing query: %s, error: %v", query, err)...)
+		return append(diags, restoreRoleLogin(ctx, c, name, blocked)...)
 	}
 	d.SetId("")
 	return diags
 }
+
+// restoreRoleLogin allows a role to log in again that was made nologin for dropping it, after the drop did not
+// succeed
+func restoreRoleLogin(ctx context.Context, c *client.Client, name string, blocked bool) diag.Diagnostics {
+	if !blocked {
+		return nil
+	}
+	query, _, err := c.Exec(ctx, "", "resourceRoleDelete", fmt.Sprintf("alter role %s with login", pq.QuoteIdentifier(name)))
+	if err != nil {
+		return diag.Errorf("Error allowing role %s to log in again, executing query: %s, error: %v", name, query, err)
+	}
+	return nil
+}
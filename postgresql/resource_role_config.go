@@ -0,0 +1,136 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/lib/pq"
+	_ "github.com/lib/pq"
+	"github.com/scastria/terraform-provider-postgresql/postgresql/client"
+)
+
+func resourceRoleConfig() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceRoleConfigCreate,
+		ReadContext:   resourceRoleConfigRead,
+		UpdateContext: resourceRoleConfigUpdate,
+		DeleteContext: resourceRoleConfigDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"role": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"database": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+			"config": {
+				Type:     schema.TypeMap,
+				Required: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+// roleConfigTarget returns the target of ALTER ... SET for role, limited to database if it is not empty
+func roleConfigTarget(role string, database string) string {
+	if database == "" {
+		return fmt.Sprintf("role %s", pq.QuoteIdentifier(role))
+	}
+	return fmt.Sprintf("role %s in database %s", pq.QuoteIdentifier(role), pq.QuoteIdentifier(database))
+}
+
+func resourceRoleConfigCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	role := d.Get("role").(string)
+	database := d.Get("database").(string)
+	query, err := updateConfig(ctx, c, "resourceRoleConfigCreate", roleConfigTarget(role, database), map[string]interface{}{}, d.Get("config").(map[string]interface{}))
+	if err != nil {
+		d.SetId("")
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	d.SetId(fmt.Sprintf("%s:%s", role, database))
+	return diags
+}
+
+func resourceRoleConfigRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	tokens := strings.Split(d.Id(), ":")
+	role := tokens[0]
+	database := tokens[1]
+	var setconfig pq.StringArray
+	// Settings for all databases are stored with setdatabase 0
+	query, row, err := c.QueryRow(ctx, "", "select s.setconfig from pg_catalog.pg_roles r left join pg_catalog.pg_db_role_setting s on s.setrole = r.oid and s.setdatabase = case when $2::text = '' then 0 else (select d.oid from pg_catalog.pg_database d where d.datname = $2::text) end where r.rolname = $1", role, database)
+	if err != nil {
+		d.SetId("")
+		return diag.FromErr(err)
+	}
+	err = row.Scan(&setconfig)
+	if err != nil {
+		d.SetId("")
+		if errors.Is(err, sql.ErrNoRows) {
+			return diags
+		}
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	// Only the managed parameters are compared, so that other parameters like the one of postgresql_role_default_role
+	// can coexist.  After an import nothing is managed yet and every parameter is taken over.
+	managed := d.Get("config").(map[string]interface{})
+	config := matchConfigKeys(parseConfig(setconfig), managed)
+	if len(managed) > 0 {
+		for key := range config {
+			_, exists := managed[key]
+			if !exists {
+				delete(config, key)
+			}
+		}
+	}
+	d.Set("role", role)
+	d.Set("database", database)
+	d.Set("config", config)
+	return diags
+}
+
+func resourceRoleConfigUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	tokens := strings.Split(d.Id(), ":")
+	role := tokens[0]
+	database := tokens[1]
+	oldConfig, newConfig := d.GetChange("config")
+	query, err := updateConfig(ctx, c, "resourceRoleConfigUpdate", roleConfigTarget(role, database), oldConfig.(map[string]interface{}), newConfig.(map[string]interface{}))
+	if err != nil {
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	return diags
+}
+
+func resourceRoleConfigDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	tokens := strings.Split(d.Id(), ":")
+	role := tokens[0]
+	database := tokens[1]
+	query, err := updateConfig(ctx, c, "resourceRoleConfigDelete", roleConfigTarget(role, database), d.Get("config").(map[string]interface{}), map[string]interface{}{})
+	if err != nil {
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	d.SetId("")
+	return diags
+}
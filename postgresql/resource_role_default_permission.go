@@ -2,6 +2,7 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
@@ -66,6 +67,12 @@ func resourceRoleDefaultPermission() *schema.Resource {
 				Optional: true,
 				ForceNew: true,
 			},
+			"with_grant_option": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
 		},
 	}
 }
@@ -80,14 +87,14 @@ func resourceRoleDefaultPermissionCreate(ctx context.Context, d *schema.Resource
 	creator := d.Get("creator").(string)
 	creatorClause := ""
 	if creator != "" {
-		creatorClause = fmt.Sprintf("for role \"%s\"", creator)
+		creatorClause = fmt.Sprintf("for role %s", pq.QuoteIdentifier(creator))
 	}
 	filter := d.Get("filter").(string)
 	filterClause := ""
 	if filter != "" {
-		filterClause = fmt.Sprintf("in schema %s", filter)
+		filterClause = fmt.Sprintf("in schema %s", pq.QuoteIdentifier(filter))
 	}
-	query, _, err := c.Exec(ctx, database, "resourceRoleDefaultPermissionCreate", "alter default privileges %s %s grant %s on %s to \"%s\"", creatorClause, filterClause, privilege, level, role)
+	query, _, err := c.Exec(ctx, database, "resourceRoleDefaultPermissionCreate", fmt.Sprintf("alter default privileges %s %s grant %s on %s to %s%s", creatorClause, filterClause, privilege, level, pq.QuoteIdentifier(role), withGrantOptionClause(d.Get("with_grant_option").(bool))))
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
@@ -96,16 +103,18 @@ func resourceRoleDefaultPermissionCreate(ctx context.Context, d *schema.Resource
 	return diags
 }
 
-func hasDefaultPrivilege(ctx context.Context, c *client.Client, role string, database string, privilege string, level string, creator string, filter string) (bool, error) {
-	var creatorRole string
-	if creator == "" {
-		creatorRole = "current_role"
-	} else {
-		creatorRole = fmt.Sprintf("'%s'", creator)
+// hasDefaultPrivilege returns whether role has the default privilege and whether it comes with the grant option
+func hasDefaultPrivilege(ctx context.Context, c *client.Client, role string, database string, privilege string, level string, creator string, filter string) (bool, bool, error) {
+	args := []any{}
+	creatorRole := "current_role"
+	if creator != "" {
+		args = append(args, creator)
+		creatorRole = fmt.Sprintf("$%d", len(args))
 	}
 	filterClause := ""
 	if filter != "" {
-		filterClause = fmt.Sprintf("and defaclnamespace::regnamespace = '%s'::regnamespace", filter)
+		args = append(args, filter)
+		filterClause = fmt.Sprintf("and defaclnamespace = (select oid from pg_catalog.pg_namespace where nspname = $%d)", len(args))
 	}
 	var objectType string
 	switch level {
@@ -123,31 +132,37 @@ func hasDefaultPrivilege(ctx context.Context, c *client.Client, role string, dat
 		objectType = "T"
 	}
 	var privs pq.StringArray
-	query, row, err := c.QueryRow(ctx, database, "select defaclacl from pg_catalog.pg_default_acl where defaclrole = %s::regrole %s and defaclobjtype = '%s'", creatorRole, filterClause, objectType)
+	args = append(args, objectType)
+	query, row, err := c.QueryRow(ctx, database, fmt.Sprintf("select defaclacl from pg_catalog.pg_default_acl where defaclrole = (select oid from pg_catalog.pg_roles where rolname = %s) %s and defaclobjtype = $%d", creatorRole, filterClause, len(args)), args...)
 	if err != nil {
-		return false, err
+		return false, false, err
 	}
 	err = row.Scan(&privs)
 	if err != nil {
-		return false, fmt.Errorf("Error executing query: %s, error: %w", query, err)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The row is deleted once the default privileges are back to the built-in ones
+			return false, false, nil
+		}
+		return false, false, fmt.Errorf("Error executing query: %s, error: %w", query, err)
 	}
 	if privs == nil {
-		return false, nil
+		return false, false, nil
 	}
 	for _, priv := range privs {
 		acl, err := pgacl.Parse(priv)
 		if err != nil {
-			return false, fmt.Errorf("Error parsing ACL: %s, error: %w", priv, err)
+			return false, false, fmt.Errorf("Error parsing ACL: %s, error: %w", priv, err)
 		}
-		if acl.Role != pq.QuoteIdentifier(role) {
+		if pgacl.UnquoteRole(acl.Role) != role {
 			continue
 		}
-		if !acl.GetPrivilege(getDefaultPrivilegeSet(privilege, level)) {
+		privileges := getDefaultPrivilegeSet(privilege, level)
+		if !acl.GetPrivilege(privileges) {
 			continue
 		}
-		return true, nil
+		return true, acl.GetGrantOption(privileges), nil
 	}
-	return false, nil
+	return false, false, nil
 }
 
 func getDefaultPrivilegeSet(privilege string, level string) pgacl.Privileges {
@@ -201,14 +216,15 @@ func resourceRoleDefaultPermissionRead(ctx context.Context, d *schema.ResourceDa
 	level := tokens[3]
 	creator := tokens[4]
 	filter := tokens[5]
-	hasPriv, err := hasDefaultPrivilege(ctx, c, role, database, privilege, level, creator, filter)
+	hasPriv, hasOption, err := hasDefaultPrivilege(ctx, c, role, database, privilege, level, creator, filter)
 	if err != nil {
-		d.SetId("")
 		var dneErr *client.DatabaseNotExistError
 		if errors.As(err, &dneErr) {
 			// Database does not exist, so the permission cannot exist
+			d.SetId("")
 			return diags
 		}
+		// Any other error, like a connection failure, must not remove the permission from state
 		return diag.FromErr(err)
 	}
 	if !hasPriv {
@@ -227,6 +243,7 @@ func resourceRoleDefaultPermissionRead(ctx context.Context, d *schema.ResourceDa
 	if filter != "" {
 		d.Set("filter", filter)
 	}
+	d.Set("with_grant_option", hasOption)
 	return diags
 }
 
@@ -241,14 +258,14 @@ func resourceRoleDefaultPermissionDelete(ctx context.Context, d *schema.Resource
 	creator := tokens[4]
 	creatorClause := ""
 	if creator != "" {
-		creatorClause = fmt.Sprintf("for role \"%s\"", creator)
+		creatorClause = fmt.Sprintf("for role %s", pq.QuoteIdentifier(creator))
 	}
 	filter := tokens[5]
 	filterClause := ""
 	if filter != "" {
-		filterClause = fmt.Sprintf("in schema %s", filter)
+		filterClause = fmt.Sprintf("in schema %s", pq.QuoteIdentifier(filter))
 	}
-	query, _, err := c.Exec(ctx, database, "resourceRoleDefaultPermissionDelete", "alter default privileges %s %s revoke %s on %s from \"%s\"", creatorClause, filterClause, privilege, level, role)
+	query, _, err := c.Exec(ctx, database, "resourceRoleDefaultPermissionDelete", fmt.Sprintf("alter default privileges %s %s revoke %s on %s from %s", creatorClause, filterClause, privilege, level, pq.QuoteIdentifier(role)))
 	if err != nil {
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
 	}
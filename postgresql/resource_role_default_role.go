@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -41,7 +42,7 @@ func resourceRoleDefaultRoleCreate(ctx context.Context, d *schema.ResourceData,
 	c := m.(*client.Client)
 	role := d.Get("role").(string)
 	default_role := d.Get("default").(string)
-	query, _, err := c.Exec(ctx, "", "resourceRoleDefaultRoleCreate", "alter role \"%s\" set role = '%s'", role, default_role)
+	query, _, err := c.Exec(ctx, "", "resourceRoleDefaultRoleCreate", fmt.Sprintf("alter role %s set role = %s", pq.QuoteIdentifier(role), pq.QuoteLiteral(default_role)))
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
@@ -55,7 +56,7 @@ func resourceRoleDefaultRoleRead(ctx context.Context, d *schema.ResourceData, m
 	c := m.(*client.Client)
 	role := d.Id()
 	var rolconfig pq.StringArray
-	query, row, err := c.QueryRow(ctx, "", "select rolconfig from pg_catalog.pg_roles where rolname = '%s'", role)
+	query, row, err := c.QueryRow(ctx, "", "select rolconfig from pg_catalog.pg_roles where rolname = $1", role)
 	if err != nil {
 		d.SetId("")
 		return diag.FromErr(err)
@@ -94,7 +95,7 @@ func resourceRoleDefaultRoleUpdate(ctx context.Context, d *schema.ResourceData,
 	c := m.(*client.Client)
 	role := d.Id()
 	default_role := d.Get("default").(string)
-	query, _, err := c.Exec(ctx, "", "resourceRoleDefaultRoleUpdate", "alter role \"%s\" set role = '%s'", role, default_role)
+	query, _, err := c.Exec(ctx, "", "resourceRoleDefaultRoleUpdate", fmt.Sprintf("alter role %s set role = %s", pq.QuoteIdentifier(role), pq.QuoteLiteral(default_role)))
 	if err != nil {
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
 	}
@@ -105,7 +106,7 @@ func resourceRoleDefaultRoleDelete(ctx context.Context, d *schema.ResourceData,
 	var diags diag.Diagnostics
 	c := m.(*client.Client)
 	role := d.Id()
-	query, _, err := c.Exec(ctx, "", "resourceRoleDefaultRoleDelete", "alter role \"%s\" set role = default", role)
+	query, _, err := c.Exec(ctx, "", "resourceRoleDefaultRoleDelete", fmt.Sprintf("alter role %s set role = default", pq.QuoteIdentifier(role)))
 	if err != nil {
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
 	}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/lib/pq"
 	_ "github.com/lib/pq"
 	"github.com/scastria/terraform-provider-postgresql/postgresql/client"
 )
@@ -19,6 +20,7 @@ func resourceRoleMember() *schema.Resource {
 		ReadContext:   resourceRoleMemberRead,
 		UpdateContext: resourceRoleMemberUpdate,
 		DeleteContext: resourceRoleMemberDelete,
+		CustomizeDiff: resourceRoleMemberCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -52,6 +54,32 @@ func resourceRoleMember() *schema.Resource {
 	}
 }
 
+func resourceRoleMemberCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	c := m.(*client.Client)
+	if d.Get("inherit").(bool) && d.Get("set").(bool) {
+		return nil
+	}
+	// Before PostgreSQL 16 inherit was a property of the member role and set did not exist
+	return c.RequireServerVersion("", client.Version16, "Role membership with inherit or set false")
+}
+
+// grantRoleMember grants role to member with the membership options the server supports
+func grantRoleMember(ctx context.Context, c *client.Client, resourceLockName string, role string, member string, admin bool, inherit bool, set bool) (string, error) {
+	version, err := c.ServerVersion("")
+	if err != nil {
+		return "", err
+	}
+	var query string
+	if version >= client.Version16 {
+		query, _, err = c.Exec(ctx, "", resourceLockName, fmt.Sprintf("grant %s to %s with admin %t, inherit %t, set %t", pq.QuoteIdentifier(role), pq.QuoteIdentifier(member), admin, inherit, set))
+	} else if admin {
+		query, _, err = c.Exec(ctx, "", resourceLockName, fmt.Sprintf("grant %s to %s with admin option", pq.QuoteIdentifier(role), pq.QuoteIdentifier(member)))
+	} else {
+		query, _, err = c.Exec(ctx, "", resourceLockName, fmt.Sprintf("grant %s to %s", pq.QuoteIdentifier(role), pq.QuoteIdentifier(member)))
+	}
+	return query, err
+}
+
 func resourceRoleMemberCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*client.Client)
@@ -60,7 +88,7 @@ func resourceRoleMemberCreate(ctx context.Context, d *schema.ResourceData, m int
 	admin := d.Get("admin").(bool)
 	inherit := d.Get("inherit").(bool)
 	set := d.Get("set").(bool)
-	query, _, err := c.Exec(ctx, "", "resourceRoleMemberCreate", "grant \"%s\" to \"%s\" with admin %t, inherit %t, set %t", role, member, admin, inherit, set)
+	query, err := grantRoleMember(ctx, c, "resourceRoleMemberCreate", role, member, admin, inherit, set)
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
@@ -75,8 +103,17 @@ func resourceRoleMemberRead(ctx context.Context, d *schema.ResourceData, m inter
 	tokens := strings.Split(d.Id(), ":")
 	role := tokens[0]
 	member := tokens[1]
+	version, err := c.ServerVersion("")
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	var admin, inherit, set bool
-	query, row, err := c.QueryRow(ctx, "", "select m.admin_option, m.inherit_option, m.set_option from pg_catalog.pg_auth_members m join pg_catalog.pg_roles mr on m.member = mr.oid join pg_catalog.pg_roles r on m.roleid = r.oid where r.rolname = '%s' and mr.rolname = '%s'", role, member)
+	// Before PostgreSQL 16 there are no per membership inherit and set options, which behave like true
+	optionColumns := "m.admin_option, true, true"
+	if version >= client.Version16 {
+		optionColumns = "m.admin_option, m.inherit_option, m.set_option"
+	}
+	query, row, err := c.QueryRow(ctx, "", fmt.Sprintf("select %s from pg_catalog.pg_auth_members m join pg_catalog.pg_roles mr on m.member = mr.oid join pg_catalog.pg_roles r on m.roleid = r.oid where r.rolname = $1 and mr.rolname = $2", optionColumns), role, member)
 	if err != nil {
 		d.SetId("")
 		return diag.FromErr(err)
@@ -106,7 +143,17 @@ func resourceRoleMemberUpdate(ctx context.Context, d *schema.ResourceData, m int
 	admin := d.Get("admin").(bool)
 	inherit := d.Get("inherit").(bool)
 	set := d.Get("set").(bool)
-	query, _, err := c.Exec(ctx, "", "resourceRoleMemberUpdate", "grant \"%s\" to \"%s\" with admin %t, inherit %t, set %t", role, member, admin, inherit, set)
+	version, err := c.ServerVersion("")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var query string
+	if (version < client.Version16) && !admin {
+		// Granting again does not remove the admin option on older servers
+		query, _, err = c.Exec(ctx, "", "resourceRoleMemberUpdate", fmt.Sprintf("revoke admin option for %s from %s", pq.QuoteIdentifier(role), pq.QuoteIdentifier(member)))
+	} else {
+		query, err = grantRoleMember(ctx, c, "resourceRoleMemberUpdate", role, member, admin, inherit, set)
+	}
 	if err != nil {
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
 	}
@@ -119,7 +166,7 @@ func resourceRoleMemberDelete(ctx context.Context, d *schema.ResourceData, m int
 	tokens := strings.Split(d.Id(), ":")
 	role := tokens[0]
 	member := tokens[1]
-	query, _, err := c.Exec(ctx, "", "resourceRoleMemberDelete", "revoke \"%s\" from \"%s\"", role, member)
+	query, _, err := c.Exec(ctx, "", "resourceRoleMemberDelete", fmt.Sprintf("revoke %s from %s", pq.QuoteIdentifier(role), pq.QuoteIdentifier(member)))
 	if err != nil {
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
 	}
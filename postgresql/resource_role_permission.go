@@ -4,12 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
-	"unicode"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/lib/pq"
 	_ "github.com/lib/pq"
 	"github.com/scastria/terraform-provider-postgresql/postgresql/client"
 )
@@ -53,6 +54,7 @@ const (
 	USAGE          = "usage"
 	SET            = "set"
 	ALTER_SYSTEM   = "alter system"
+	MAINTAIN       = "maintain"
 	ALL_PRIVILEGES = "all privileges"
 	SUPERUSER      = "superuser"
 	CREATE_DB      = "createdb"
@@ -60,11 +62,51 @@ const (
 	BYPASS_RLS     = "bypassrls"
 )
 
+const (
+	// Object types of all tables in schema
+	VIEW              = "view"
+	MATERIALIZED_VIEW = "materialized_view"
+	FOREIGN_TABLE     = "foreign_table"
+	PARTITIONED_TABLE = "partitioned_table"
+)
+
+// tableObjectTypes maps the object types of all tables in schema to their pg_class.relkind
+var tableObjectTypes = map[string]string{
+	TABLE:             "r",
+	VIEW:              "v",
+	MATERIALIZED_VIEW: "m",
+	FOREIGN_TABLE:     "f",
+	PARTITIONED_TABLE: "p",
+}
+
+// bulkLevels maps the levels covering all objects of a kind in a schema to the level of a single such object
+var bulkLevels = map[string]string{
+	ALL_FUNCTIONS:  FUNCTION,
+	ALL_PROCEDURES: PROCEDURE,
+	ALL_ROUTINES:   ROUTINE,
+	ALL_SEQUENCES:  SEQUENCE,
+	ALL_TABLES:     TABLE,
+}
+
+// bulkObjectQueries select the objects in schema $1 covered by each bulk level, leaving out those whose name matches
+// any of the like patterns in $2.  Routines are identified by their signature of argument types only, without the
+// names and modes of pg_get_function_identity_arguments, which regprocedure input does not accept.  Tables are limited to the relkinds in
+// $3 and leave out partitions, whose privileges are checked on their parent when accessed through it.
+var bulkObjectQueries = map[string]string{
+	ALL_FUNCTIONS:  "select p.proname || '(' || pg_catalog.oidvectortypes(p.proargtypes) || ')' sig from pg_catalog.pg_proc p join pg_catalog.pg_namespace n on n.oid = p.pronamespace where n.nspname = $1 and p.prokind <> 'p' and not p.proname like any($2) order by sig",
+	ALL_PROCEDURES: "select p.proname || '(' || pg_catalog.oidvectortypes(p.proargtypes) || ')' sig from pg_catalog.pg_proc p join pg_catalog.pg_namespace n on n.oid = p.pronamespace where n.nspname = $1 and p.prokind = 'p' and not p.proname like any($2) order by sig",
+	ALL_ROUTINES:   "select p.proname || '(' || pg_catalog.oidvectortypes(p.proargtypes) || ')' sig from pg_catalog.pg_proc p join pg_catalog.pg_namespace n on n.oid = p.pronamespace where n.nspname = $1 and not p.proname like any($2) order by sig",
+	ALL_SEQUENCES:  "select c.relname from pg_catalog.pg_class c join pg_catalog.pg_namespace n on n.oid = c.relnamespace where n.nspname = $1 and c.relkind = 'S' and not c.relname like any($2) order by c.relname",
+	ALL_TABLES:     "select c.relname from pg_catalog.pg_class c join pg_catalog.pg_namespace n on n.oid = c.relnamespace where n.nspname = $1 and c.relkind::text = any($3) and not c.relispartition and not c.relname like any($2) order by c.relname",
+}
+
 func resourceRolePermission() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceRolePermissionCreate,
 		ReadContext:   resourceRolePermissionRead,
+		UpdateContext: resourceRolePermissionUpdate,
 		DeleteContext: resourceRolePermissionDelete,
+		CustomizeDiff: resourceRolePermissionCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -83,7 +125,7 @@ func resourceRolePermission() *schema.Resource {
 				Type:         schema.TypeString,
 				Required:     true,
 				ForceNew:     true,
-				ValidateFunc: validation.StringInSlice([]string{SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER, CREATE, CONNECT, TEMPORARY, EXECUTE, USAGE, SET, ALTER_SYSTEM, ALL_PRIVILEGES, SUPERUSER, CREATE_DB, CREATE_ROLE, BYPASS_RLS}, false),
+				ValidateFunc: validation.StringInSlice([]string{SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER, CREATE, CONNECT, TEMPORARY, EXECUTE, USAGE, SET, ALTER_SYSTEM, MAINTAIN, ALL_PRIVILEGES, SUPERUSER, CREATE_DB, CREATE_ROLE, BYPASS_RLS}, false),
 			},
 			"level": {
 				Type:         schema.TypeString,
@@ -99,10 +141,93 @@ func resourceRolePermission() *schema.Resource {
 				ForceNew:     true,
 				RequiredWith: []string{"level"},
 			},
+			"with_grant_option": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"granted_by": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"revoke_cascade": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"exclude": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotEmpty,
+				},
+			},
+			"object_types": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice([]string{TABLE, VIEW, MATERIALIZED_VIEW, FOREIGN_TABLE, PARTITIONED_TABLE}, false),
+				},
+			},
+			"objects": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
 		},
 	}
 }
 
+func resourceRolePermissionCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	c := m.(*client.Client)
+	level := d.Get("level").(string)
+	filter := newBulkFilter(d.Get)
+	if _, ok := bulkLevels[level]; !ok && (len(filter.exclude) > 0) {
+		return fmt.Errorf("exclude can only be specified for levels %s, %s, %s, %s and %s", ALL_FUNCTIONS, ALL_PROCEDURES, ALL_ROUTINES, ALL_SEQUENCES, ALL_TABLES)
+	}
+	if (level != ALL_TABLES) && (len(filter.objectTypes) > 0) {
+		return fmt.Errorf("object_types can only be specified for level %s", ALL_TABLES)
+	}
+	if _, ok := bulkLevels[level]; ok && (d.Id() != "") && d.NewValueKnown("target") && d.NewValueKnown("exclude") && d.NewValueKnown("object_types") {
+		// Plan the objects currently in the schema, so that those missing the privilege show up as added
+		objects, err := listBulkObjects(ctx, c, d.Get("database").(string), level, d.Get("target").(string), filter)
+		if err != nil {
+			return err
+		}
+		planned := schema.NewSet(schema.HashString, nil)
+		for _, object := range objects {
+			planned.Add(object)
+		}
+		if !planned.Equal(d.Get("objects")) {
+			err = d.SetNew("objects", planned)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if (d.Get("level").(string) == GLOBAL) && (d.Get("with_grant_option").(bool) || (d.Get("granted_by").(string) != "")) {
+		return fmt.Errorf("with_grant_option and granted_by cannot be specified for level %s", GLOBAL)
+	}
+	if d.Get("granted_by").(string) != "" {
+		err := c.RequireServerVersion("", client.Version14, "granted_by")
+		if err != nil {
+			return err
+		}
+	}
+	if level == PARAMETER {
+		return c.RequireServerVersion("", client.Version15, "Privileges on parameters")
+	}
+	if d.Get("privilege").(string) == MAINTAIN {
+		return c.RequireServerVersion("", client.Version17, "The maintain privilege")
+	}
+	return nil
+}
+
 func resourceRolePermissionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	c := m.(*client.Client)
@@ -111,39 +236,220 @@ func resourceRolePermissionCreate(ctx context.Context, d *schema.ResourceData, m
 	privilege := d.Get("privilege").(string)
 	level := d.Get("level").(string)
 	target := d.Get("target").(string)
+	filter := newBulkFilter(d.Get)
 	var query string
 	var err error
 	if level == GLOBAL {
-		query, _, err = c.Exec(ctx, "", "resourceRolePermissionCreate", "alter role \"%s\" %s", role, privilege)
+		query, _, err = c.Exec(ctx, "", "resourceRolePermissionCreate", fmt.Sprintf("alter role %s %s", pq.QuoteIdentifier(role), privilege))
 	} else {
-		query, _, err = c.Exec(ctx, database, "resourceRolePermissionCreate", "grant %s on %s %s to \"%s\"", privilege, level, target, role)
+		var grantTarget string
+		grantTarget, err = permissionTarget(ctx, c, database, level, target, filter)
+		if err != nil {
+			d.SetId("")
+			return diag.FromErr(err)
+		}
+		if grantTarget != "" {
+			query, _, err = c.Exec(ctx, database, "resourceRolePermissionCreate", fmt.Sprintf("grant %s on %s to %s%s%s", privilege, grantTarget, pq.QuoteIdentifier(role), withGrantOptionClause(d.Get("with_grant_option").(bool)), grantedByClause(d.Get("granted_by").(string))))
+		}
 	}
 	if err != nil {
 		d.SetId("")
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
 	}
 	d.SetId(fmt.Sprintf("%s:%s:%s:%s:%s", role, database, privilege, level, target))
+	if _, ok := bulkLevels[level]; ok {
+		objects, err := listBulkObjects(ctx, c, database, level, target, filter)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("objects", objects)
+	}
 	return diags
 }
 
-func fixCaseSensitiveIdentifier(identifier string) string {
-	// Check if the string contains uppercase letters
-	hasUppercase := strings.IndexFunc(identifier, unicode.IsUpper) != -1
-	if !hasUppercase {
-		return identifier
+// bulkFilter narrows down the objects covered by a bulk level
+type bulkFilter struct {
+	// Like patterns of the names of the objects to leave out
+	exclude []string
+	// Object types of all tables in schema to limit the tables to, all of them if empty
+	objectTypes []string
+}
+
+// newBulkFilter returns the bulkFilter configured by the exclude and object_types attributes read through get
+func newBulkFilter(get func(string) interface{}) bulkFilter {
+	return bulkFilter{
+		exclude:     toStrings(get("exclude").([]interface{})),
+		objectTypes: sortedStrings(get("object_types").(*schema.Set)),
+	}
+}
+
+// listBulkObjects returns the objects in schemaName covered by the bulk level, narrowed down by filter
+func listBulkObjects(ctx context.Context, c *client.Client, database string, level string, schemaName string, filter bulkFilter) ([]string, error) {
+	// A nil array would be sent as null, which excludes everything
+	args := []any{schemaName, pq.StringArray(append([]string{}, filter.exclude...))}
+	if level == ALL_TABLES {
+		objectTypes := filter.objectTypes
+		if len(objectTypes) == 0 {
+			objectTypes = []string{TABLE, VIEW, MATERIALIZED_VIEW, FOREIGN_TABLE, PARTITIONED_TABLE}
+		}
+		var relkinds pq.StringArray
+		for _, objectType := range objectTypes {
+			relkinds = append(relkinds, tableObjectTypes[objectType])
+		}
+		args = append(args, relkinds)
+	}
+	query, rows, err := c.Query(ctx, database, bulkObjectQueries[level], args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error executing query: %s, error: %w", query, err)
+	}
+	defer rows.Close()
+	var objects []string
+	for rows.Next() {
+		var object string
+		err = rows.Scan(&object)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
 	}
+	return objects, rows.Err()
+}
+
+// quoteBulkObject quotes an object returned by listBulkObjects, qualified by schemaName
+func quoteBulkObject(level string, schemaName string, object string) string {
+	name := object
+	signature := ""
+	if idx := strings.IndexByte(object, '('); (level != ALL_TABLES) && (level != ALL_SEQUENCES) && (idx != -1) {
+		name = object[:idx]
+		signature = object[idx:]
+	}
+	return fmt.Sprintf("%s.%s%s", pq.QuoteIdentifier(schemaName), pq.QuoteIdentifier(name), signature)
+}
+
+// quoteBulkObjects quotes objects returned by listBulkObjects so that they can be embedded into a grant or revoke
+// statement at the level of a single such object
+func quoteBulkObjects(level string, schemaName string, objects []string) string {
+	var quoted []string
+	for _, object := range objects {
+		quoted = append(quoted, quoteBulkObject(level, schemaName, object))
+	}
+	return fmt.Sprintf("%s %s", bulkLevels[level], strings.Join(quoted, ", "))
+}
+
+// permissionTarget returns what a grant or revoke statement at level applies to.  Bulk levels narrowed down by filter
+// list the remaining objects instead, returning an empty string if there are none.
+func permissionTarget(ctx context.Context, c *client.Client, database string, level string, target string, filter bulkFilter) (string, error) {
+	if _, ok := bulkLevels[level]; ok && ((len(filter.exclude) > 0) || (len(filter.objectTypes) > 0)) {
+		objects, err := listBulkObjects(ctx, c, database, level, target, filter)
+		if (err != nil) || (len(objects) == 0) {
+			return "", err
+		}
+		return quoteBulkObjects(level, target, objects), nil
+	}
+	quotedTarget, err := quoteTarget(level, target)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s", level, quotedTarget), nil
+}
+
+// bulkPrivilegeObjects returns the objects covered by the bulk level that role has privilege on
+func bulkPrivilegeObjects(ctx context.Context, c *client.Client, role string, database string, privilege string, level string, target string, filter bulkFilter, grantOption bool) ([]string, error) {
+	// All objects are listed before checking any of them, which needs the connection again
+	objects, err := listBulkObjects(ctx, c, database, level, target, filter)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, object := range objects {
+		quotedObject := quoteBulkObject(level, target, object)
+		var hasPriv bool
+		switch level {
+		case ALL_TABLES:
+			hasPriv, err = hasTablePrivilege(ctx, c, role, database, privilege, quotedObject, grantOption)
+		case ALL_SEQUENCES:
+			hasPriv, err = hasSequencePrivilege(ctx, c, role, database, privilege, quotedObject, grantOption)
+		default:
+			hasPriv, err = hasFunctionPrivilege(ctx, c, role, database, privilege, quotedObject, grantOption)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hasPriv {
+			result = append(result, object)
+		}
+	}
+	return result, nil
+}
+
+// withGrantOptionClause returns the clause of a grant statement that lets the grantee grant the privilege to others.
+// Appended to a privilege passed to has_*_privilege, it checks for the grant option instead.
+func withGrantOptionClause(grantOption bool) string {
+	if grantOption {
+		return " with grant option"
+	}
+	return ""
+}
+
+// grantedByClause returns the clause of a grant or revoke statement that names the grantor
+func grantedByClause(grantedBy string) string {
+	if grantedBy == "" {
+		return ""
+	}
+	return fmt.Sprintf(" granted by %s", pq.QuoteIdentifier(grantedBy))
+}
+
+// revokeCascadeClause returns the clause of a revoke statement that also revokes the privileges granted by the
+// grantee to others through its grant option
+func revokeCascadeClause(cascade bool) string {
+	if cascade {
+		return " cascade"
+	}
+	return ""
+}
+
+// quoteQualifiedIdentifier quotes each part of a possibly schema qualified identifier like MySchema.MyTable
+func quoteQualifiedIdentifier(identifier string) string {
 	tokens := strings.Split(identifier, ".")
 	for i, token := range tokens {
-		tokens[i] = fmt.Sprintf("\"%s\"", token)
+		tokens[i] = pq.QuoteIdentifier(token)
 	}
 	return strings.Join(tokens, ".")
 }
 
-func hasPrivilege(ctx context.Context, c *client.Client, role string, database string, privilege string, level string, target string) (bool, error) {
-	targetCaseSensitive := fixCaseSensitiveIdentifier(target)
+// quoteRoutineSignature quotes the name of a routine signature like MySchema.MyFunction(integer, text), leaving
+// the argument types untouched
+func quoteRoutineSignature(signature string) string {
+	idx := strings.IndexByte(signature, '(')
+	if idx == -1 {
+		return quoteQualifiedIdentifier(signature)
+	}
+	return quoteQualifiedIdentifier(strings.TrimSpace(signature[:idx])) + signature[idx:]
+}
+
+// quoteTarget quotes target so that it can be embedded into a grant or revoke statement at level
+func quoteTarget(level string, target string) (string, error) {
+	switch level {
+	case LARGE_OBJECT:
+		_, err := strconv.ParseUint(target, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("Invalid large object oid: %s", target)
+		}
+		return target, nil
+	case FUNCTION, PROCEDURE, ROUTINE:
+		return quoteRoutineSignature(target), nil
+	case TABLE, SEQUENCE, TYPE, DOMAIN, PARAMETER:
+		return quoteQualifiedIdentifier(target), nil
+	default:
+		return pq.QuoteIdentifier(target), nil
+	}
+}
+
+func hasPrivilege(ctx context.Context, c *client.Client, role string, database string, privilege string, level string, target string, grantOption bool) (bool, error) {
+	option := withGrantOptionClause(grantOption)
 	if level == GLOBAL {
 		var super, createdb, createrole, bypass bool
-		query, row, err := c.QueryRow(ctx, "", "select rolsuper, rolcreatedb, rolcreaterole, rolbypassrls from pg_catalog.pg_roles where rolname = '%s'", role)
+		query, row, err := c.QueryRow(ctx, "", "select rolsuper, rolcreatedb, rolcreaterole, rolbypassrls from pg_catalog.pg_roles where rolname = $1", role)
 		if err != nil {
 			return false, err
 		}
@@ -165,7 +471,7 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 		}
 	} else if level == DATABASE {
 		var hasCreate, hasConnect, hasTemporary bool
-		query, row, err := c.QueryRow(ctx, "", "select has_database_privilege('%s', '%s', '%s'), has_database_privilege('%s', '%s', '%s'), has_database_privilege('%s', '%s', '%s')", role, targetCaseSensitive, CREATE, role, targetCaseSensitive, CONNECT, role, targetCaseSensitive, TEMPORARY)
+		query, row, err := c.QueryRow(ctx, "", "select has_database_privilege($1, $2, $3), has_database_privilege($1, $2, $4), has_database_privilege($1, $2, $5)", role, target, CREATE+option, CONNECT+option, TEMPORARY+option)
 		if err != nil {
 			return false, err
 		}
@@ -188,7 +494,7 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 	} else if level == DOMAIN {
 		var hasUsage bool
 		// domain is a special form of type so use has_type_privilege
-		query, row, err := c.QueryRow(ctx, database, "select has_type_privilege('%s', '%s', '%s')", role, targetCaseSensitive, USAGE)
+		query, row, err := c.QueryRow(ctx, database, "select has_type_privilege($1, $2, $3)", role, quoteQualifiedIdentifier(target), USAGE+option)
 		if err != nil {
 			return false, err
 		}
@@ -204,7 +510,7 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 		}
 	} else if level == FOREIGN_DATA_WRAPPER {
 		var hasUsage bool
-		query, row, err := c.QueryRow(ctx, database, "select has_foreign_data_wrapper_privilege('%s', '%s', '%s')", role, targetCaseSensitive, USAGE)
+		query, row, err := c.QueryRow(ctx, database, "select has_foreign_data_wrapper_privilege($1, $2, $3)", role, target, USAGE+option)
 		if err != nil {
 			return false, err
 		}
@@ -220,7 +526,7 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 		}
 	} else if level == FOREIGN_SERVER {
 		var hasUsage bool
-		query, row, err := c.QueryRow(ctx, database, "select has_server_privilege('%s', '%s', '%s')", role, targetCaseSensitive, USAGE)
+		query, row, err := c.QueryRow(ctx, database, "select has_server_privilege($1, $2, $3)", role, target, USAGE+option)
 		if err != nil {
 			return false, err
 		}
@@ -236,7 +542,7 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 		}
 	} else if level == LANGUAGE {
 		var hasUsage bool
-		query, row, err := c.QueryRow(ctx, database, "select has_language_privilege('%s', '%s', '%s')", role, targetCaseSensitive, USAGE)
+		query, row, err := c.QueryRow(ctx, database, "select has_language_privilege($1, $2, $3)", role, target, USAGE+option)
 		if err != nil {
 			return false, err
 		}
@@ -252,7 +558,7 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 		}
 	} else if level == LARGE_OBJECT {
 		var hasSelect, hasUpdate bool
-		query, row, err := c.QueryRow(ctx, database, "select has_large_object_privilege('%s', '%s', '%s'), has_large_object_privilege('%s', '%s', '%s')", role, targetCaseSensitive, SELECT, role, targetCaseSensitive, UPDATE)
+		query, row, err := c.QueryRow(ctx, database, "select has_large_object_privilege($1, $2, $3), has_large_object_privilege($1, $2, $4)", role, target, SELECT+option, UPDATE+option)
 		if err != nil {
 			return false, err
 		}
@@ -271,7 +577,7 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 		}
 	} else if level == PARAMETER {
 		var hasSet, hasAlter bool
-		query, row, err := c.QueryRow(ctx, database, "select has_parameter_privilege('%s', '%s', '%s'), has_parameter_privilege('%s', '%s', '%s')", role, targetCaseSensitive, SET, role, targetCaseSensitive, ALTER_SYSTEM)
+		query, row, err := c.QueryRow(ctx, database, "select has_parameter_privilege($1, $2, $3), has_parameter_privilege($1, $2, $4)", role, target, SET+option, ALTER_SYSTEM+option)
 		if err != nil {
 			return false, err
 		}
@@ -290,7 +596,7 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 		}
 	} else if level == SCHEMA {
 		var hasCreate, hasUsage bool
-		query, row, err := c.QueryRow(ctx, database, "select has_schema_privilege('%s', '%s', '%s'), has_schema_privilege('%s', '%s', '%s')", role, targetCaseSensitive, CREATE, role, targetCaseSensitive, USAGE)
+		query, row, err := c.QueryRow(ctx, database, "select has_schema_privilege($1, $2, $3), has_schema_privilege($1, $2, $4)", role, target, CREATE+option, USAGE+option)
 		if err != nil {
 			return false, err
 		}
@@ -309,7 +615,7 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 		}
 	} else if level == TABLESPACE {
 		var hasCreate bool
-		query, row, err := c.QueryRow(ctx, database, "select has_tablespace_privilege('%s', '%s', '%s')", role, targetCaseSensitive, CREATE)
+		query, row, err := c.QueryRow(ctx, database, "select has_tablespace_privilege($1, $2, $3)", role, target, CREATE+option)
 		if err != nil {
 			return false, err
 		}
@@ -325,7 +631,7 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 		}
 	} else if level == TYPE {
 		var hasUsage bool
-		query, row, err := c.QueryRow(ctx, database, "select has_type_privilege('%s', '%s', '%s')", role, targetCaseSensitive, USAGE)
+		query, row, err := c.QueryRow(ctx, database, "select has_type_privilege($1, $2, $3)", role, quoteQualifiedIdentifier(target), USAGE+option)
 		if err != nil {
 			return false, err
 		}
@@ -340,108 +646,51 @@ func hasPrivilege(ctx context.Context, c *client.Client, role string, database s
 			return false, nil
 		}
 	} else if level == SEQUENCE {
-		hasPriv, err := hasSequencePrivilege(ctx, c, role, database, privilege, target)
+		hasPriv, err := hasSequencePrivilege(ctx, c, role, database, privilege, quoteQualifiedIdentifier(target), grantOption)
 		if err != nil {
 			return false, err
 		}
 		if !hasPriv {
 			return false, nil
 		}
-	} else if level == ALL_SEQUENCES {
-		// Get all sequences
-		query, rows, err := c.Query(ctx, database, "select sequence_name from information_schema.sequences where sequence_schema = '%s' order by sequence_name", target)
-		if err != nil {
-			return false, fmt.Errorf("Error executing query: %s, error: %w", query, err)
-		}
-		defer rows.Close()
-		for rows.Next() {
-			var name string
-			err = rows.Scan(&name)
-			if err != nil {
-				return false, err
-			}
-			hasPriv, err := hasSequencePrivilege(ctx, c, role, database, privilege, fmt.Sprintf("%s.%s", target, name))
-			if err != nil {
-				return false, err
-			}
-			if !hasPriv {
-				return false, nil
-			}
-		}
 	} else if (level == FUNCTION) || (level == PROCEDURE) || (level == ROUTINE) {
-		hasPriv, err := hasFunctionPrivilege(ctx, c, role, database, privilege, target)
+		hasPriv, err := hasFunctionPrivilege(ctx, c, role, database, privilege, quoteRoutineSignature(target), grantOption)
 		if err != nil {
 			return false, err
 		}
 		if !hasPriv {
 			return false, nil
 		}
-	} else if (level == ALL_FUNCTIONS) || (level == ALL_PROCEDURES) || (level == ALL_ROUTINES) {
-		// Get all items
-		var inFilter string
-		if level == ALL_FUNCTIONS {
-			inFilter = "'f'"
-		} else if level == ALL_PROCEDURES {
-			inFilter = "'p'"
-		} else {
-			inFilter = "'f', 'p'"
-		}
-		query, rows, err := c.Query(ctx, database, "select p.oid::regprocedure sig from pg_proc p join pg_catalog.pg_namespace n on n.oid = p.pronamespace where n.nspname = '%s' and p.prokind in (%s) order by sig", target, inFilter)
-		if err != nil {
-			return false, fmt.Errorf("Error executing query: %s, error: %w", query, err)
-		}
-		defer rows.Close()
-		for rows.Next() {
-			var name string
-			err = rows.Scan(&name)
-			if err != nil {
-				return false, err
-			}
-			hasPriv, err := hasFunctionPrivilege(ctx, c, role, database, privilege, name)
-			if err != nil {
-				return false, err
-			}
-			if !hasPriv {
-				return false, nil
-			}
-		}
 	} else if level == TABLE {
-		hasPriv, err := hasTablePrivilege(ctx, c, role, database, privilege, target)
+		hasPriv, err := hasTablePrivilege(ctx, c, role, database, privilege, quoteQualifiedIdentifier(target), grantOption)
 		if err != nil {
 			return false, err
 		}
 		if !hasPriv {
 			return false, nil
 		}
-	} else if level == ALL_TABLES {
-		// Get all tables and views
-		query, rows, err := c.Query(ctx, database, "select table_name from information_schema.tables where table_schema = '%s' order by table_name", target)
-		if err != nil {
-			return false, fmt.Errorf("Error executing query: %s, error: %w", query, err)
-		}
-		defer rows.Close()
-		for rows.Next() {
-			var name string
-			err = rows.Scan(&name)
-			if err != nil {
-				return false, err
-			}
-			hasPriv, err := hasTablePrivilege(ctx, c, role, database, privilege, fmt.Sprintf("%s.%s", target, name))
-			if err != nil {
-				return false, err
-			}
-			if !hasPriv {
-				return false, nil
-			}
-		}
 	}
 	return true, nil
 }
 
-func hasTablePrivilege(ctx context.Context, c *client.Client, role string, database string, privilege string, table string) (bool, error) {
-	tableCaseSensitive := fixCaseSensitiveIdentifier(table)
+// hasTablePrivilege expects table to already be quoted
+func hasTablePrivilege(ctx context.Context, c *client.Client, role string, database string, privilege string, table string, grantOption bool) (bool, error) {
+	option := withGrantOptionClause(grantOption)
+	if privilege == MAINTAIN {
+		// Only exists since PostgreSQL 17, so it is not checked together with the others
+		var hasMaintain bool
+		query, row, err := c.QueryRow(ctx, database, "select has_table_privilege($1, $2, $3)", role, table, MAINTAIN+option)
+		if err != nil {
+			return false, err
+		}
+		err = row.Scan(&hasMaintain)
+		if err != nil {
+			return false, fmt.Errorf("Error executing query: %s, error: %w", query, err)
+		}
+		return hasMaintain, nil
+	}
 	var hasSelect, hasInsert, hasUpdate, hasDelete, hasTruncate, hasReferences, hasTrigger bool
-	query, row, err := c.QueryRow(ctx, database, "select has_table_privilege('%s', '%s', '%s'), has_table_privilege('%s', '%s', '%s'), has_table_privilege('%s', '%s', '%s'), has_table_privilege('%s', '%s', '%s'), has_table_privilege('%s', '%s', '%s'), has_table_privilege('%s', '%s', '%s'), has_table_privilege('%s', '%s', '%s')", role, tableCaseSensitive, SELECT, role, tableCaseSensitive, INSERT, role, tableCaseSensitive, UPDATE, role, tableCaseSensitive, DELETE, role, tableCaseSensitive, TRUNCATE, role, tableCaseSensitive, REFERENCES, role, tableCaseSensitive, TRIGGER)
+	query, row, err := c.QueryRow(ctx, database, "select has_table_privilege($1, $2, $3), has_table_privilege($1, $2, $4), has_table_privilege($1, $2, $5), has_table_privilege($1, $2, $6), has_table_privilege($1, $2, $7), has_table_privilege($1, $2, $8), has_table_privilege($1, $2, $9)", role, table, SELECT+option, INSERT+option, UPDATE+option, DELETE+option, TRUNCATE+option, REFERENCES+option, TRIGGER+option)
 	if err != nil {
 		return false, err
 	}
@@ -476,10 +725,11 @@ func hasTablePrivilege(ctx context.Context, c *client.Client, role string, datab
 	return true, nil
 }
 
-func hasFunctionPrivilege(ctx context.Context, c *client.Client, role string, database string, privilege string, function string) (bool, error) {
-	functionCaseSensitive := fixCaseSensitiveIdentifier(function)
+// hasFunctionPrivilege expects function to already be quoted
+func hasFunctionPrivilege(ctx context.Context, c *client.Client, role string, database string, privilege string, function string, grantOption bool) (bool, error) {
+	option := withGrantOptionClause(grantOption)
 	var hasExecute bool
-	query, row, err := c.QueryRow(ctx, database, "select has_function_privilege('%s', '%s', '%s')", role, functionCaseSensitive, EXECUTE)
+	query, row, err := c.QueryRow(ctx, database, "select has_function_privilege($1, $2, $3)", role, function, EXECUTE+option)
 	if err != nil {
 		return false, err
 	}
@@ -496,10 +746,11 @@ func hasFunctionPrivilege(ctx context.Context, c *client.Client, role string, da
 	return true, nil
 }
 
-func hasSequencePrivilege(ctx context.Context, c *client.Client, role string, database string, privilege string, sequence string) (bool, error) {
-	sequenceCaseSensitive := fixCaseSensitiveIdentifier(sequence)
+// hasSequencePrivilege expects sequence to already be quoted
+func hasSequencePrivilege(ctx context.Context, c *client.Client, role string, database string, privilege string, sequence string, grantOption bool) (bool, error) {
+	option := withGrantOptionClause(grantOption)
 	var hasUsage, hasSelect, hasUpdate bool
-	query, row, err := c.QueryRow(ctx, database, "select has_sequence_privilege('%s', '%s', '%s'), has_sequence_privilege('%s', '%s', '%s'), has_sequence_privilege('%s', '%s', '%s')", role, sequenceCaseSensitive, USAGE, role, sequenceCaseSensitive, SELECT, role, sequenceCaseSensitive, UPDATE)
+	query, row, err := c.QueryRow(ctx, database, "select has_sequence_privilege($1, $2, $3), has_sequence_privilege($1, $2, $4), has_sequence_privilege($1, $2, $5)", role, sequence, USAGE+option, SELECT+option, UPDATE+option)
 	if err != nil {
 		return false, err
 	}
@@ -531,28 +782,114 @@ func resourceRolePermissionRead(ctx context.Context, d *schema.ResourceData, m i
 	privilege := tokens[2]
 	level := tokens[3]
 	target := tokens[4]
-	hasPriv, err := hasPrivilege(ctx, c, role, database, privilege, level, target)
+	if _, ok := bulkLevels[level]; ok {
+		// Objects missing the privilege are left out, so that they are granted again by an update
+		objects, err := bulkPrivilegeObjects(ctx, c, role, database, privilege, level, target, newBulkFilter(d.Get), d.Get("with_grant_option").(bool))
+		if err != nil {
+			var dneErr *client.DatabaseNotExistError
+			if errors.As(err, &dneErr) {
+				// Database does not exist, so the permission cannot exist
+				d.SetId("")
+				return diags
+			}
+			return diag.FromErr(err)
+		}
+		d.Set("objects", objects)
+	} else {
+		diags = readRolePermission(ctx, c, d, role, database, privilege, level, target)
+		if diags.HasError() || (d.Id() == "") {
+			return diags
+		}
+	}
+	d.Set("role", role)
+	if database != "" {
+		d.Set("database", database)
+	}
+	d.Set("privilege", privilege)
+	d.Set("level", level)
+	if target != "" {
+		d.Set("target", target)
+	}
+	return diags
+}
+
+// readRolePermission checks a permission at a level other than the bulk ones, clearing the id if it does not exist
+func readRolePermission(ctx context.Context, c *client.Client, d *schema.ResourceData, role string, database string, privilege string, level string, target string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	hasPriv, err := hasPrivilege(ctx, c, role, database, privilege, level, target, false)
 	if err != nil {
-		d.SetId("")
 		var dneErr *client.DatabaseNotExistError
 		if errors.As(err, &dneErr) {
 			// Database does not exist, so the permission cannot exist
+			d.SetId("")
 			return diags
 		}
+		// Any other error, like a connection failure, must not remove the permission from state
 		return diag.FromErr(err)
 	}
 	if !hasPriv {
 		d.SetId("")
 		return diags
 	}
-	d.Set("role", role)
-	if database != "" {
-		d.Set("database", database)
+	// Superusers and owners implicitly have every grant option, so it is only verified when it is expected
+	if (level != GLOBAL) && d.Get("with_grant_option").(bool) {
+		hasOption, err := hasPrivilege(ctx, c, role, database, privilege, level, target, true)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("with_grant_option", hasOption)
 	}
-	d.Set("privilege", privilege)
-	d.Set("level", level)
-	if target != "" {
-		d.Set("target", target)
+	return diags
+}
+
+func resourceRolePermissionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	tokens := strings.Split(d.Id(), ":")
+	role := tokens[0]
+	database := tokens[1]
+	privilege := tokens[2]
+	level := tokens[3]
+	target := tokens[4]
+	grantedBy := grantedByClause(d.Get("granted_by").(string))
+	if d.HasChange("with_grant_option") {
+		grantTarget, err := permissionTarget(ctx, c, database, level, target, newBulkFilter(d.Get))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if grantTarget != "" {
+			var query string
+			if d.Get("with_grant_option").(bool) {
+				query, _, err = c.Exec(ctx, database, "resourceRolePermissionUpdate", fmt.Sprintf("grant %s on %s to %s with grant option%s", privilege, grantTarget, pq.QuoteIdentifier(role), grantedBy))
+			} else {
+				query, _, err = c.Exec(ctx, database, "resourceRolePermissionUpdate", fmt.Sprintf("revoke grant option for %s on %s from %s%s%s", privilege, grantTarget, pq.QuoteIdentifier(role), grantedBy, revokeCascadeClause(d.Get("revoke_cascade").(bool))))
+			}
+			if err != nil {
+				return diag.Errorf("Error executing query: %s, error: %v", query, err)
+			}
+		}
+	}
+	if _, ok := bulkLevels[level]; ok && d.HasChange("objects") {
+		// Grant the privilege again on the objects that were missing it, like tables created since
+		o, n := d.GetChange("objects")
+		if d.HasChanges("exclude", "object_types") {
+			// Revoke the privilege from the objects no longer covered, like those matching a new exclude pattern or
+			// of a relkind dropped from object_types
+			uncovered := sortedStrings(o.(*schema.Set).Difference(n.(*schema.Set)))
+			if len(uncovered) > 0 {
+				query, _, err := c.Exec(ctx, database, "resourceRolePermissionUpdate", fmt.Sprintf("revoke %s on %s from %s%s%s", privilege, quoteBulkObjects(level, target, uncovered), pq.QuoteIdentifier(role), grantedBy, revokeCascadeClause(d.Get("revoke_cascade").(bool))))
+				if err != nil {
+					return diag.Errorf("Error executing query: %s, error: %v", query, err)
+				}
+			}
+		}
+		missing := sortedStrings(n.(*schema.Set).Difference(o.(*schema.Set)))
+		if len(missing) > 0 {
+			query, _, err := c.Exec(ctx, database, "resourceRolePermissionUpdate", fmt.Sprintf("grant %s on %s to %s%s%s", privilege, quoteBulkObjects(level, target, missing), pq.QuoteIdentifier(role), withGrantOptionClause(d.Get("with_grant_option").(bool)), grantedBy))
+			if err != nil {
+				return diag.Errorf("Error executing query: %s, error: %v", query, err)
+			}
+		}
 	}
 	return diags
 }
@@ -569,9 +906,16 @@ func resourceRolePermissionDelete(ctx context.Context, d *schema.ResourceData, m
 	var query string
 	var err error
 	if level == GLOBAL {
-		query, _, err = c.Exec(ctx, "", "resourceRolePermissionDelete", "alter role \"%s\" %s", role, fmt.Sprintf("no%s", privilege))
+		query, _, err = c.Exec(ctx, "", "resourceRolePermissionDelete", fmt.Sprintf("alter role %s no%s", pq.QuoteIdentifier(role), privilege))
 	} else {
-		query, _, err = c.Exec(ctx, database, "resourceRolePermissionDelete", "revoke %s on %s %s from \"%s\"", privilege, level, target, role)
+		var revokeTarget string
+		revokeTarget, err = permissionTarget(ctx, c, database, level, target, newBulkFilter(d.Get))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if revokeTarget != "" {
+			query, _, err = c.Exec(ctx, database, "resourceRolePermissionDelete", fmt.Sprintf("revoke %s on %s from %s%s%s", privilege, revokeTarget, pq.QuoteIdentifier(role), grantedByClause(d.Get("granted_by").(string)), revokeCascadeClause(d.Get("revoke_cascade").(bool))))
+		}
 	}
 	if err != nil {
 		return diag.Errorf("Error executing query: %s, error: %v", query, err)
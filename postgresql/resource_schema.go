@@ -0,0 +1,145 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/lib/pq"
+	_ "github.com/lib/pq"
+	"github.com/scastria/terraform-provider-postgresql/postgresql/client"
+)
+
+func resourceSchema() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSchemaCreate,
+		ReadContext:   resourceSchemaRead,
+		UpdateContext: resourceSchemaUpdate,
+		DeleteContext: resourceSchemaDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"database": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"owner": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"if_not_exists": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"drop_cascade": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+func resourceSchemaCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	database := d.Get("database").(string)
+	name := d.Get("name").(string)
+	ifNotExistsOption := ""
+	if d.Get("if_not_exists").(bool) {
+		ifNotExistsOption = "if not exists"
+	}
+	authorizationOption := ""
+	if owner := d.Get("owner").(string); owner != "" {
+		authorizationOption = fmt.Sprintf("authorization %s", pq.QuoteIdentifier(owner))
+	}
+	query, _, err := c.Exec(ctx, database, "resourceSchemaCreate", fmt.Sprintf("create schema %s %s %s", ifNotExistsOption, pq.QuoteIdentifier(name), authorizationOption))
+	if err != nil {
+		d.SetId("")
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	d.SetId(fmt.Sprintf("%s.%s", database, name))
+	return diags
+}
+
+func resourceSchemaRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	// The database is the part before the first dot, so schema names may contain dots but database names may not
+	database, name, _ := strings.Cut(d.Id(), ".")
+	var owner string
+	query, row, err := c.QueryRow(ctx, database, "select pg_catalog.pg_get_userbyid(nspowner) from pg_catalog.pg_namespace where nspname = $1", name)
+	if err != nil {
+		var dneErr *client.DatabaseNotExistError
+		if errors.As(err, &dneErr) {
+			// Database does not exist, so the schema cannot exist
+			d.SetId("")
+			return diags
+		}
+		return diag.FromErr(err)
+	}
+	err = row.Scan(&owner)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			d.SetId("")
+			return diags
+		}
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	d.Set("database", database)
+	d.Set("name", name)
+	d.Set("owner", owner)
+	return diags
+}
+
+func resourceSchemaUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	database := d.Get("database").(string)
+	if d.HasChange("name") {
+		oldName, newName := d.GetChange("name")
+		query, _, err := c.Exec(ctx, database, "resourceSchemaUpdate", fmt.Sprintf("alter schema %s rename to %s", pq.QuoteIdentifier(oldName.(string)), pq.QuoteIdentifier(newName.(string))))
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+		d.SetId(fmt.Sprintf("%s.%s", database, newName.(string)))
+	}
+	name := d.Get("name").(string)
+	if d.HasChange("owner") {
+		query, _, err := c.Exec(ctx, database, "resourceSchemaUpdate", fmt.Sprintf("alter schema %s owner to %s", pq.QuoteIdentifier(name), pq.QuoteIdentifier(d.Get("owner").(string))))
+		if err != nil {
+			return diag.Errorf("Error executing query: %s, error: %v", query, err)
+		}
+	}
+	return diags
+}
+
+func resourceSchemaDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+	database := d.Get("database").(string)
+	name := d.Get("name").(string)
+	dropOption := "restrict"
+	if d.Get("drop_cascade").(bool) {
+		dropOption = "cascade"
+	}
+	query, _, err := c.Exec(ctx, database, "resourceSchemaDelete", fmt.Sprintf("drop schema %s %s", pq.QuoteIdentifier(name), dropOption))
+	if err != nil {
+		return diag.Errorf("Error executing query: %s, error: %v", query, err)
+	}
+	d.SetId("")
+	return diags
+}